@@ -1,6 +1,10 @@
 package apperror
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
 
 //AppError : Custom Error type which contains http status code and message
 type AppError struct {
@@ -12,8 +16,13 @@ var (
 	BadRequest  = AppError{http.StatusBadRequest, "Bad request body received"}
 	ServerError = AppError{http.StatusInternalServerError, "An error occurred while processing that request"}
 	NotFound    = AppError{http.StatusNotFound, "The requested resource was not found"}
+	Timeout     = AppError{http.StatusGatewayTimeout, "The request took too long to process"}
+	Canceled    = AppError{StatusClientClosedRequest, "The request was canceled by the client"}
 )
 
+// StatusClientClosedRequest : the status nginx logs when a client goes away before it is answered, nobody receives it
+const StatusClientClosedRequest = 499
+
 func NewError(status int, message string) AppError {
 	return AppError{Status: status, Message: message}
 }
@@ -21,3 +30,25 @@ func NewError(status int, message string) AppError {
 func (e AppError) Error() string {
 	return e.Message
 }
+
+//InvalidParameter : A parameter of a request which isn't valid, along with the reason why
+type InvalidParameter struct {
+	Name   string `json:"name"`
+	In     string `json:"in"` // where the parameter was sent, query or body
+	Reason string `json:"reason"`
+}
+
+//ValidationError : Error returned when one or more parameters of a request aren't valid
+type ValidationError struct {
+	Parameters []InvalidParameter
+}
+
+func (e ValidationError) Error() string {
+	var reasons []string
+
+	for _, parameter := range e.Parameters {
+		reasons = append(reasons, fmt.Sprintf("%s %s", parameter.Name, parameter.Reason))
+	}
+
+	return "invalid parameters: " + strings.Join(reasons, "; ")
+}
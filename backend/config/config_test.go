@@ -0,0 +1,37 @@
+package config
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTrustedProxiesEnv(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.0.2.1,2001:db8::1")
+
+	proxies, err := trustedProxiesEnv()
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Len(t, proxies, 3)
+
+	require.True(t, proxies.Trusts("10.20.30.40:5000"))
+	require.True(t, proxies.Trusts("192.0.2.1:5000"))
+	require.True(t, proxies.Trusts("[2001:db8::1]:5000"))
+	require.False(t, proxies.Trusts("192.0.2.2:5000"))
+	require.False(t, proxies.Trusts("not an address"))
+
+	// nothing is trusted unless it's configured
+	t.Setenv("TRUSTED_PROXIES", "")
+
+	proxies, err = trustedProxiesEnv()
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.False(t, proxies.Trusts("10.20.30.40:5000"))
+
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/33")
+
+	_, err = trustedProxiesEnv()
+	require.Error(t, err, "Expected An Error\nGot: %v\n", err)
+
+	t.Setenv("TRUSTED_PROXIES", "proxy.internal")
+
+	_, err = trustedProxiesEnv()
+	require.Error(t, err, "Expected An Error\nGot: %v\n", err)
+}
@@ -3,9 +3,10 @@ module assessment
 go 1.18
 
 require (
-	github.com/golang/mock v1.6.0
 	github.com/gorilla/mux v1.8.0
 	github.com/joho/godotenv v1.4.0
+	github.com/klauspost/compress v1.17.2
+	github.com/lib/pq v1.10.9
 	github.com/mattn/go-sqlite3 v1.14.14
 	github.com/stretchr/testify v1.8.0
 )
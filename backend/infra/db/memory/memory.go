@@ -0,0 +1,254 @@
+package memory
+
+import (
+	"assessment/config"
+	"assessment/model"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// checkEvery : how many records are scanned between checks of the context
+const checkEvery = 1024
+
+/*Repo : Keeps the phone numbers in memory, seeded from a CSV or JSON file
+The records never change once the repository is created, so it's safe to read from any number of goroutines. Results
+are filtered and ordered exactly like the SQLite repository does, so the two can be swapped with DB_DRIVER.
+*/
+type Repo struct {
+	records      []model.Record // ordered by ID
+	lastModified time.Time
+}
+
+/*NewMemoryClient : Loads the phone numbers from MEMORY_SEED_FILE, starting out empty when it isn't set
+The data is taken to have last changed when the file did, so responses cached by clients stay valid across restarts.
+*/
+func NewMemoryClient() (*Repo, error) {
+	fileName := config.FetchConfig().Memory.SeedFile
+
+	if fileName == "" {
+		return NewRepo(nil), nil
+	}
+
+	info, err := os.Stat(fileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := Load(fileName)
+
+	if err != nil {
+		return nil, fmt.Errorf("seeding from %s: %w", fileName, err)
+	}
+
+	repo, err := newRepo(records, info.ModTime())
+
+	if err != nil {
+		return nil, fmt.Errorf("seeding from %s: %w", fileName, err)
+	}
+
+	return repo, nil
+}
+
+//NewRepo : Creates a repository holding the records provided, which panics if two of them have the same ID
+func NewRepo(records []model.Record) *Repo {
+	repo, err := newRepo(records, time.Now())
+
+	if err != nil {
+		panic(err)
+	}
+
+	return repo
+}
+
+func newRepo(records []model.Record, lastModified time.Time) (*Repo, error) {
+	sorted := append([]model.Record{}, records...)
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].ID == sorted[i-1].ID {
+			return nil, fmt.Errorf("the ID %d is used more than once", sorted[i].ID)
+		}
+	}
+
+	return &Repo{records: sorted, lastModified: lastModified}, nil
+}
+
+/*FetchPhoneNumbers : Fetches phone numbers matching the filter, starting at the cursor
+A negative limit reads every record after the offset, like LIMIT -1 does in SQLite.
+*/
+func (repo *Repo) FetchPhoneNumbers(ctx context.Context, filter model.Filter, cursor model.Cursor) ([]model.Record, error) {
+	return repo.page(ctx, filter, cursor)
+}
+
+/*ScanPhoneNumbers : Hands the phone numbers matching the filter to yield one at a time, starting at the cursor
+The matching records are already in memory, so this only saves the caller from collecting them itself.
+*/
+func (repo *Repo) ScanPhoneNumbers(ctx context.Context, filter model.Filter, cursor model.Cursor, yield func(model.Record) bool) error {
+	if cursor.Limit <= 0 {
+		cursor.Limit = -1
+	}
+
+	records, err := repo.page(ctx, filter, cursor)
+
+	if err != nil {
+		return err
+	}
+
+	for i, record := range records {
+		// yield may take a while with every record, e.g. validating it
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		if !yield(record) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+//page : the records matching the filter, ordered and paged by the cursor
+func (repo *Repo) page(ctx context.Context, filter model.Filter, cursor model.Cursor) ([]model.Record, error) {
+	keys := withTiebreak(cursor.Sort)
+
+	// unknown fields are reported even when nothing matches
+	if err := checkSort(keys); err != nil {
+		return nil, err
+	}
+
+	records, err := repo.matching(ctx, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the records are already in the order of their IDs
+	if len(keys) > 1 || keys[0].Descending {
+		sort.SliceStable(records, func(i, j int) bool { return compare(keys, &records[i], &records[j]) < 0 })
+	}
+
+	// resume right after the record the cursor points at
+	if cursor.After != nil {
+		records = records[sort.Search(len(records), func(i int) bool { return compare(keys, &records[i], cursor.After) > 0 }):]
+	}
+
+	if cursor.Offset >= len(records) {
+		return nil, nil
+	}
+
+	records = records[cursor.Offset:]
+
+	if cursor.Limit >= 0 && cursor.Limit < len(records) {
+		records = records[:cursor.Limit]
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	return records, nil
+}
+
+//CountPhoneNumbers : Counts the phone numbers matching the filter, ignoring the state
+func (repo *Repo) CountPhoneNumbers(ctx context.Context, filter model.Filter) (int, error) {
+	records, err := repo.matching(ctx, filter)
+	return len(records), err
+}
+
+//LastModified : When the seed file last changed, or when the repository was created when it wasn't seeded from a file
+func (repo *Repo) LastModified(ctx context.Context) (time.Time, error) {
+	return repo.lastModified, ctx.Err()
+}
+
+//matching : copies the records matching the filter, in the order of their IDs
+func (repo *Repo) matching(ctx context.Context, filter model.Filter) ([]model.Record, error) {
+	var result []model.Record
+
+	for i := range repo.records {
+		// scanning every record takes a while on a large seed, so it stops once the caller gives up
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		if matches(filter, &repo.records[i]) {
+			result = append(result, repo.records[i])
+		}
+	}
+
+	return result, nil
+}
+
+//matches : checks the record against the filter the same way the SQLite repository's WHERE clause does
+func matches(filter model.Filter, record *model.Record) bool {
+	// a number matches when its bracketed digits start with any of the included codes
+	if len(filter.Codes) > 0 && !hasCode(record.Phone, filter.Codes) {
+		return false
+	}
+
+	if filter.Search.Number != "" {
+		national := nationalNumber(record.Phone)
+
+		if filter.Search.Prefix && !strings.HasPrefix(national, filter.Search.Number) {
+			return false
+		}
+
+		if !filter.Search.Prefix && !strings.Contains(national, filter.Search.Number) {
+			return false
+		}
+	}
+
+	// every term has to start one of the words in the name
+	if len(filter.Search.Terms) > 0 {
+		words := strings.FieldsFunc(strings.ToLower(record.Name), isSeparator)
+
+		for _, term := range filter.Search.Terms {
+			if !startsAny(words, term) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func hasCode(phone string, codes []string) bool {
+	for _, code := range codes {
+		if strings.HasPrefix(phone, "("+code) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//nationalNumber : everything after the bracketed country code, the whole number when it has none
+func nationalNumber(phone string) string {
+	return strings.TrimLeft(phone[strings.Index(phone, ")")+1:], " ")
+}
+
+func startsAny(words []string, term string) bool {
+	for _, word := range words {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//isSeparator : words are split on anything but letters and digits, like the SQLite full text index splits them
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
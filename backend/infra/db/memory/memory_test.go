@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"assessment/model"
+	"assessment/repository"
+	"assessment/repository/repotest"
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+var testRecords = []model.Record{
+	{ID: 6, Name: "Ávila-Ramos", Phone: "(251) 914701723"},
+	{ID: 1, Name: "Walid Hammadi", Phone: "(212) 6007989253"},
+	{ID: 2, Name: "yosaf karrouch", Phone: "(212) 698054317"},
+	{ID: 3, Name: "Ann 100% Sure", Phone: "(237) 697151594"},
+	{ID: 4, Name: "ann lee", Phone: "(237) 697_51594"},
+	{ID: 5, Name: "", Phone: "(258) 847651504"},
+}
+
+func ids(records []model.Record) []int64 {
+	var result []int64
+
+	for _, record := range records {
+		result = append(result, record.ID)
+	}
+
+	return result
+}
+
+func TestRepo_CountPhoneNumbers(t *testing.T) {
+	repo := NewRepo(testRecords)
+
+	count := func(filter model.Filter) int {
+		count, err := repo.CountPhoneNumbers(context.Background(), filter)
+		require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+		return count
+	}
+
+	require.Equal(t, 6, count(model.Filter{}))
+	require.Equal(t, 4, count(model.Filter{Codes: []string{"212", "237"}}))
+	require.Equal(t, 2, count(model.Filter{Search: model.Search{Number: "5159"}}))
+	require.Equal(t, 1, count(model.Filter{Search: model.Search{Number: "600", Prefix: true}}))
+	require.Equal(t, 0, count(model.Filter{Search: model.Search{Number: "212", Prefix: true}}))
+	require.Equal(t, 1, count(model.Filter{Search: model.Search{Number: "697_"}}))
+
+	// terms match the start of any word in the name, ignoring case
+	require.Equal(t, 2, count(model.Filter{Search: model.Search{Terms: []string{"ann"}}}))
+	require.Equal(t, 1, count(model.Filter{Search: model.Search{Terms: []string{"ann", "le"}}}))
+	require.Equal(t, 1, count(model.Filter{Search: model.Search{Terms: []string{"ramos"}}}))
+	require.Equal(t, 0, count(model.Filter{Search: model.Search{Terms: []string{"nn"}}}))
+}
+
+func TestRepo_FetchPhoneNumbers(t *testing.T) {
+	repo := NewRepo(testRecords)
+
+	fetch := func(filter model.Filter, cursor model.Cursor) []model.Record {
+		records, err := repo.FetchPhoneNumbers(context.Background(), filter, cursor)
+		require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+		return records
+	}
+
+	require.Equal(t, []model.Record{testRecords[3], testRecords[4]}, fetch(model.Filter{Codes: []string{"237"}}, model.Cursor{Limit: 10}))
+
+	// pages are read by offset or after a record
+	require.Equal(t, []int64{3, 4}, ids(fetch(model.Filter{}, model.Cursor{Limit: 2, Offset: 2})))
+	require.Equal(t, []int64{5, 6}, ids(fetch(model.Filter{}, model.Cursor{Limit: 2, After: &testRecords[4]})))
+	require.Equal(t, []int64{3, 4, 5, 6}, ids(fetch(model.Filter{}, model.Cursor{Limit: -1, Offset: 2})))
+	require.Empty(t, fetch(model.Filter{}, model.Cursor{Limit: 0}))
+	require.Empty(t, fetch(model.Filter{}, model.Cursor{Limit: 2, Offset: 6}))
+
+	// names are ordered like in SQLite: missing names first, then ignoring the case of ASCII letters, ties broken by ID
+	byName := []model.SortKey{{Field: "name"}}
+	require.Equal(t, []int64{5, 3, 4, 1, 2, 6}, ids(fetch(model.Filter{}, model.Cursor{Sort: byName, Limit: 10})))
+	require.Equal(t, []int64{1, 2, 6}, ids(fetch(model.Filter{}, model.Cursor{Sort: byName, Limit: 10, After: &testRecords[4]})))
+
+	descending := []model.SortKey{{Field: "phone", Descending: true}}
+	require.Equal(t, []int64{5, 6, 4, 3}, ids(fetch(model.Filter{}, model.Cursor{Sort: descending, Limit: 4})))
+
+	_, err := repo.FetchPhoneNumbers(context.Background(), model.Filter{}, model.Cursor{Sort: []model.SortKey{{Field: "state"}}})
+	require.Error(t, err)
+}
+
+func TestRepo_Canceled(t *testing.T) {
+	repo := NewRepo(testRecords)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.FetchPhoneNumbers(ctx, model.Filter{}, model.Cursor{Limit: 1})
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, err = repo.CountPhoneNumbers(ctx, model.Filter{})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestNewRepo_DuplicateIDs(t *testing.T) {
+	_, err := newRepo([]model.Record{{ID: 1, Phone: "(212) 698054317"}, {ID: 1, Phone: "(212) 6546545369"}}, time.Now())
+	require.Error(t, err)
+}
+
+func TestRepo_Conformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T, records []model.Record) repository.PhoneNumberRepository {
+		return NewRepo(records)
+	})
+}
@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"assessment/model"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+//seedRecord : a customer as written in a JSON seed file, a missing or null name is stored as an empty one
+type seedRecord struct {
+	ID    *int64  `json:"id"`
+	Name  *string `json:"name"`
+	Phone string  `json:"phone"`
+}
+
+/*Load : Reads the records in a seed file, which is either CSV or JSON depending on its extension
+A CSV file starts with a header naming its columns, in any order: id and phone are required, name is optional.
+	id,name,phone
+	1,Walid Hammadi,(212) 6007989253
+A JSON file holds an array of customers with the same fields.
+	[{"id": 1, "name": "Walid Hammadi", "phone": "(212) 6007989253"}]
+*/
+func Load(fileName string) ([]model.Record, error) {
+	file, err := os.Open(fileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = file.Close() }()
+
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		return ReadCSV(file)
+	case ".json":
+		return ReadJSON(file)
+	}
+
+	return nil, fmt.Errorf("seed files should be .csv or .json, got %q", filepath.Base(fileName))
+}
+
+//ReadCSV : Reads records from CSV with a header row
+func ReadCSV(r io.Reader) ([]model.Record, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("the CSV is missing its header")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int)
+
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range []string{"id", "phone"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("the CSV header is missing the %s column", name)
+		}
+	}
+
+	var records []model.Record
+
+	for {
+		row, err := reader.Read()
+
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		id, err := strconv.ParseInt(row[columns["id"]], 10, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: the id should be a number, got %q", line, row[columns["id"]])
+		}
+
+		record := model.Record{ID: id, Phone: row[columns["phone"]]}
+
+		if column, ok := columns["name"]; ok {
+			record.Name = row[column]
+		}
+
+		if record.Phone == "" {
+			return nil, fmt.Errorf("line %d: the phone is missing", line)
+		}
+
+		records = append(records, record)
+	}
+}
+
+//ReadJSON : Reads records from a JSON array of customers
+func ReadJSON(r io.Reader) ([]model.Record, error) {
+	var customers []seedRecord
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&customers); err != nil {
+		return nil, err
+	}
+
+	records := make([]model.Record, 0, len(customers))
+
+	for i, customer := range customers {
+		if customer.ID == nil {
+			return nil, fmt.Errorf("customer %d: the id is missing", i)
+		}
+
+		if customer.Phone == "" {
+			return nil, fmt.Errorf("customer %d: the phone is missing", i)
+		}
+
+		record := model.Record{ID: *customer.ID, Phone: customer.Phone}
+
+		if customer.Name != nil {
+			record.Name = *customer.Name
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"assessment/model"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	records, err := ReadCSV(strings.NewReader("phone,id,name\n\"(212) 698054317\",1,\"Karrouch, Yosaf\"\n(258) 847651504,2,\n"))
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Equal(t, []model.Record{
+		{ID: 1, Name: "Karrouch, Yosaf", Phone: "(212) 698054317"},
+		{ID: 2, Phone: "(258) 847651504"},
+	}, records)
+
+	// the name column is optional
+	records, err = ReadCSV(strings.NewReader("id,phone\n1,(212) 698054317\n"))
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Equal(t, []model.Record{{ID: 1, Phone: "(212) 698054317"}}, records)
+
+	for _, invalid := range []string{"", "id,name\n1,Yosaf\n", "id,phone\none,(212) 698054317\n", "id,phone\n1,\n", "id,phone\n1\n"} {
+		_, err = ReadCSV(strings.NewReader(invalid))
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	records, err := ReadJSON(strings.NewReader(`[{"id": 1, "name": "Yosaf Karrouch", "phone": "(212) 698054317"}, {"id": 2, "name": null, "phone": "(258) 847651504"}]`))
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Equal(t, []model.Record{
+		{ID: 1, Name: "Yosaf Karrouch", Phone: "(212) 698054317"},
+		{ID: 2, Phone: "(258) 847651504"},
+	}, records)
+
+	for _, invalid := range []string{"", `{"id": 1}`, `[{"name": "Yosaf", "phone": "(212) 698054317"}]`, `[{"id": 1}]`, `[{"id": 1, "phone": "1", "email": ""}]`} {
+		_, err = ReadJSON(strings.NewReader(invalid))
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, content string) string {
+		fileName := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(fileName, []byte(content), 0o600))
+
+		return fileName
+	}
+
+	records, err := Load(write("customers.CSV", "id,phone\n1,(212) 698054317\n"))
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Len(t, records, 1)
+
+	records, err = Load(write("customers.json", `[{"id": 1, "phone": "(212) 698054317"}]`))
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Len(t, records, 1)
+
+	_, err = Load(write("customers.txt", "1,(212) 698054317\n"))
+	require.Error(t, err)
+
+	_, err = Load(filepath.Join(dir, "missing.csv"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"assessment/model"
+	"assessment/repository"
+	"fmt"
+	"strings"
+)
+
+//withTiebreak : makes the order total by falling back to the ID when every other field is equal
+func withTiebreak(sort []model.SortKey) []model.SortKey {
+	for _, key := range sort {
+		if key.Field == "id" {
+			return sort
+		}
+	}
+
+	return append(append([]model.SortKey{}, sort...), model.SortKey{Field: "id"})
+}
+
+//checkSort : makes sure every field can be ordered by
+func checkSort(sort []model.SortKey) error {
+	for _, key := range sort {
+		switch key.Field {
+		case "id", "name", "phone":
+		default:
+			return fmt.Errorf("records can't be sorted by %q", key.Field)
+		}
+	}
+
+	return nil
+}
+
+//compare : orders two records by every key in turn, returning -1, 0 or 1
+func compare(sort []model.SortKey, a, b *model.Record) int {
+	for _, key := range sort {
+		var result int
+
+		switch key.Field {
+		case "id":
+			result = compareInts(a.ID, b.ID)
+		case "name":
+			result = repository.CompareNoCase(a.Name, b.Name)
+		case "phone":
+			result = strings.Compare(a.Phone, b.Phone)
+		}
+
+		if key.Descending {
+			result = -result
+		}
+
+		if result != 0 {
+			return result
+		}
+	}
+
+	return 0
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
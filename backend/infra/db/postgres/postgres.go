@@ -0,0 +1,273 @@
+package postgres
+
+import (
+	"assessment/config"
+	"assessment/infra/db/sqldb"
+	"assessment/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	_ "github.com/lib/pq"
+	"math"
+	"regexp"
+	"time"
+)
+
+/*Repo : Reads the phone numbers from a PostgreSQL database
+It behaves exactly like the SQLite repository, down to the order of names, so the two can be swapped with DB_DRIVER.
+*/
+type Repo struct {
+	db         *sql.DB
+	statements *sqldb.Statements
+}
+
+//NewPostgresClient : Connects to the database at POSTGRES_URL, whose schema has to have been set up with Migrate
+func NewPostgresClient() (*Repo, error) {
+	db, err := connect()
+
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := open(db)
+
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+/*Migrate : Sets up the schema of the database at POSTGRES_URL, creating the customer table when it doesn't exist yet
+It's a one-off command run with rights to change the schema, the backend only checks that the schema is there on start.
+A schema which is already set up is left as it is.
+*/
+func Migrate() error {
+	db, err := connect()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = db.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if err = ping(ctx, db); err != nil {
+		return err
+	}
+
+	if err = migrate(ctx, db); err != nil {
+		return fmt.Errorf("setting up the schema: %w", err)
+	}
+
+	return nil
+}
+
+//connect : a pool of connections to the database at POSTGRES_URL, opened lazily
+func connect() (*sql.DB, error) {
+	conf := config.FetchConfig().Postgres
+
+	db, err := sql.Open("postgres", conf.URL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(conf.MaxConnections)
+	db.SetMaxIdleConns(conf.MaxConnections)
+
+	return db, nil
+}
+
+//open : checks that the database can be reached and that its schema is set up
+func open(db *sql.DB) (*Repo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := ping(ctx, db); err != nil {
+		return nil, err
+	}
+
+	if err := checkSchema(ctx, db); err != nil {
+		return nil, fmt.Errorf("checking the schema: %w", err)
+	}
+
+	return &Repo{db: db, statements: sqldb.NewStatements(db, sqldb.Dollar)}, nil
+}
+
+//ping : checks that the database can be reached
+func ping(ctx context.Context, db *sql.DB) error {
+	// connections are opened lazily, a wrong URL would otherwise only show on the first request
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("connecting to postgres: %w", err)
+	}
+
+	return nil
+}
+
+/*FetchPhoneNumbers : Fetches phone numbers matching the filter from the database, starting at the cursor
+Only the country code can be checked by the database, the state of a number is computed by the service.
+*/
+func (repo *Repo) FetchPhoneNumbers(ctx context.Context, filter model.Filter, cursor model.Cursor) ([]model.Record, error) {
+	var result []model.Record
+
+	st, err := records(filter, cursor)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = repo.statements.Each(ctx, st.Page(cursor.Limit, cursor.Offset), func(record model.Record) bool {
+		result = append(result, record)
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+//ScanPhoneNumbers : Hands the phone numbers matching the filter to yield as they're read, starting at the cursor
+func (repo *Repo) ScanPhoneNumbers(ctx context.Context, filter model.Filter, cursor model.Cursor, yield func(model.Record) bool) error {
+	st, err := records(filter, cursor)
+
+	if err != nil {
+		return err
+	}
+
+	// the limit is always bound, the largest one there is reads to the end
+	limit := cursor.Limit
+
+	if limit <= 0 {
+		limit = math.MaxInt
+	}
+
+	return repo.statements.Each(ctx, st.Page(limit, cursor.Offset), yield)
+}
+
+//records : builds the statement reading the records matching the filter in the order of the cursor, without paging it
+func records(filter model.Filter, cursor model.Cursor) (*sqldb.Statement, error) {
+	st := filterBy(sqldb.Select("customer", "id", "COALESCE(name, '')", "phone"), filter)
+
+	// resume right after the record the cursor points at
+	if cursor.After != nil {
+		condition, args, err := sqldb.After(sortColumns, cursor.Sort, cursor.After)
+
+		if err != nil {
+			return nil, err
+		}
+
+		st.Where(condition, args...)
+	}
+
+	order, err := sqldb.OrderBy(sortColumns, cursor.Sort)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return st.OrderBy(order...), nil
+}
+
+//CountPhoneNumbers : Counts the phone numbers in the database matching the filter, ignoring the state
+func (repo *Repo) CountPhoneNumbers(ctx context.Context, filter model.Filter) (int, error) {
+	var count int
+
+	rows, err := repo.statements.Query(ctx, filterBy(sqldb.Select("customer", "COUNT(*)"), filter))
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() { _ = rows.Close() }()
+
+	if rows.Next() {
+		if err := rows.Scan(&count); err != nil {
+			return 0, err
+		}
+	}
+
+	return count, rows.Err()
+}
+
+/*LastModified : The last time the data in the customer table changed
+The time is kept up to date by a trigger on the table, the table is taken to have last changed when the trigger was
+created until it changes again.
+*/
+func (repo *Repo) LastModified(ctx context.Context) (time.Time, error) {
+	var lastModified time.Time
+
+	err := repo.db.QueryRowContext(ctx, "SELECT modified_at FROM customer_modified").Scan(&lastModified)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+
+	return lastModified, err
+}
+
+//Health : Checks that the database can be reached, along with the state of the connection pool
+func (repo *Repo) Health(ctx context.Context) model.Health {
+	health := model.Health{Status: "up", Pools: map[string]model.PoolStats{"postgres": sqldb.PoolStats(repo.db)}}
+
+	if err := repo.db.PingContext(ctx); err != nil {
+		health.Status, health.Error = "down", err.Error()
+	}
+
+	return health
+}
+
+//Close : Releases the prepared statements and the connections
+func (repo *Repo) Close() error {
+	err := repo.statements.Close()
+
+	if closeErr := repo.db.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+/*filterBy : adds the conditions a row has to satisfy to match the filter to the statement
+Every value sent by the client is bound to a placeholder, and escaped when it's part of a pattern.
+*/
+func filterBy(st *sqldb.Statement, filter model.Filter) *sqldb.Statement {
+	// a number matches when its bracketed digits start with any of the included codes, as a number written (1242) is
+	// validated as one of code 1, followed by the national number 242...
+	var (
+		matches []string
+		codes   []interface{}
+	)
+
+	for _, code := range filter.Codes {
+		matches = append(matches, "phone LIKE ?"+sqldb.LikeEscape)
+		codes = append(codes, sqldb.StartsWith("("+code))
+	}
+
+	st.WhereAny(matches, codes...)
+
+	// the national number is everything after the bracketed country code
+	if filter.Search.Number != "" {
+		pattern := sqldb.Containing(filter.Search.Number)
+
+		if filter.Search.Prefix {
+			pattern = sqldb.StartsWith(filter.Search.Number)
+		}
+
+		st.Where("ltrim(substr(phone, strpos(phone, ')') + 1)) LIKE ?"+sqldb.LikeEscape, pattern)
+	}
+
+	// a term matches the start of any word in the name, words being split on anything but letters and digits like the
+	// SQLite full text index splits them
+	for _, term := range filter.Search.Terms {
+		st.Where("lower(COALESCE(name, '')) ~ ?", "(^|[^[:alnum:]])"+regexp.QuoteMeta(term))
+	}
+
+	return st
+}
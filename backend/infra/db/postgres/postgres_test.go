@@ -0,0 +1,365 @@
+package postgres
+
+import (
+	"assessment/model"
+	"assessment/repository"
+	"assessment/repository/repotest"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*testURL : the database the tests run against
+It's POSTGRES_TEST_URL when set, otherwise a throwaway cluster is started by TestMain when the PostgreSQL binaries can be
+found. The tests are skipped when neither is available.
+*/
+var testURL = os.Getenv("POSTGRES_TEST_URL")
+
+func TestMain(m *testing.M) {
+	var stop func()
+
+	if testURL == "" {
+		var err error
+
+		if testURL, stop, err = startCluster(); err != nil {
+			fmt.Fprintf(os.Stderr, "postgres: not starting a test cluster: %v\n", err)
+		}
+	}
+
+	code := m.Run()
+
+	if stop != nil {
+		stop()
+	}
+
+	os.Exit(code)
+}
+
+/*startCluster : initialises a cluster in a temporary directory and starts it on a free port
+Only a unix socket is listened on, in the same directory, so nothing outside the machine can connect to it.
+*/
+func startCluster() (string, func(), error) {
+	bin, err := findBinaries()
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	// initdb refuses to run as root
+	if os.Geteuid() == 0 {
+		return "", nil, fmt.Errorf("the cluster can't be initialised as root")
+	}
+
+	dir, err := os.MkdirTemp("", "postgres-test")
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	port, err := freePort()
+
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return "", nil, err
+	}
+
+	data := filepath.Join(dir, "data")
+	run := func(name string, args ...string) error {
+		if out, err := exec.Command(filepath.Join(bin, name), args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("%s: %w\n%s", name, err, out)
+		}
+
+		return nil
+	}
+
+	err = run("initdb", "-D", data, "-U", "postgres", "--auth=trust", "--no-sync")
+
+	if err == nil {
+		options := fmt.Sprintf("-p %d -k %s -c listen_addresses='' -c fsync=off", port, dir)
+		err = run("pg_ctl", "-D", data, "-l", filepath.Join(dir, "log"), "-o", options, "-w", "start")
+	}
+
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return "", nil, err
+	}
+
+	stop := func() {
+		_ = run("pg_ctl", "-D", data, "-m", "immediate", "-w", "stop")
+		_ = os.RemoveAll(dir)
+	}
+
+	return fmt.Sprintf("host=%s port=%d user=postgres dbname=postgres sslmode=disable", dir, port), stop, nil
+}
+
+//findBinaries : the directory holding initdb and pg_ctl, either on the PATH or where Debian based systems install them
+func findBinaries() (string, error) {
+	if path, err := exec.LookPath("initdb"); err == nil {
+		return filepath.Dir(path), nil
+	}
+
+	dirs, _ := filepath.Glob("/usr/lib/postgresql/*/bin")
+
+	for _, dir := range dirs {
+		if _, err := os.Stat(filepath.Join(dir, "initdb")); err == nil {
+			return dir, nil
+		}
+	}
+
+	return "", fmt.Errorf("initdb wasn't found")
+}
+
+//freePort : a TCP port nothing is listening on
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() { _ = listener.Close() }()
+
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+//withSearchPath : adds the search_path run time parameter to either form of connection string
+func withSearchPath(connection string, schema string) string {
+	if strings.HasPrefix(connection, "postgres://") || strings.HasPrefix(connection, "postgresql://") {
+		u, err := url.Parse(connection)
+
+		if err == nil {
+			query := u.Query()
+			query.Set("search_path", schema)
+			u.RawQuery = query.Encode()
+
+			return u.String()
+		}
+	}
+
+	return connection + " search_path=" + schema
+}
+
+//newTestSchema : an empty schema of its own, dropped when the test ends
+func newTestSchema(t *testing.T) string {
+	t.Helper()
+
+	if testURL == "" {
+		t.Skip("PostgreSQL isn't available, set POSTGRES_TEST_URL to run these tests")
+	}
+
+	admin, err := sql.Open("postgres", testURL)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+
+	_, err = admin.Exec("CREATE SCHEMA " + schema)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	t.Cleanup(func() {
+		_, _ = admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		_ = admin.Close()
+	})
+
+	return schema
+}
+
+//newTestDB : connections whose search path is the schema
+func newTestDB(t *testing.T, schema string) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("postgres", withSearchPath(testURL, schema))
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	return db
+}
+
+/*newTestRepo : a repository in a schema of its own, dropped when the test ends
+The schema is set up like the migrate command does, then the records are inserted.
+*/
+func newTestRepo(t *testing.T, records ...model.Record) *Repo {
+	t.Helper()
+
+	db := newTestDB(t, newTestSchema(t))
+	err := migrate(context.Background(), db)
+
+	var repo *Repo
+
+	if err == nil {
+		repo, err = open(db)
+	}
+
+	if err != nil {
+		_ = db.Close()
+	}
+
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	t.Cleanup(func() { _ = repo.Close() })
+
+	for _, record := range records {
+		var name interface{} = record.Name
+
+		// an empty name stands for a missing one, like in the sample database
+		if record.Name == "" {
+			name = nil
+		}
+
+		_, err = db.Exec("INSERT INTO customer (id, name, phone) VALUES ($1, $2, $3)", record.ID, name, record.Phone)
+		require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	}
+
+	return repo
+}
+
+var testRecords = []model.Record{
+	{ID: 1, Name: "Walid Hammadi", Phone: "(212) 6007989253"},
+	{ID: 2, Name: "yosaf karrouch", Phone: "(212) 698054317"},
+	{ID: 3, Name: "Ann 100% Sure", Phone: "(237) 697151594"},
+	{ID: 4, Name: "ann lee", Phone: "(237) 697_51594"},
+	{ID: 5, Name: "", Phone: "(258) 847651504"},
+	{ID: 6, Name: "Ávila-Ramos", Phone: "(251) 914701723"},
+}
+
+func ids(records []model.Record) []int64 {
+	var result []int64
+
+	for _, record := range records {
+		result = append(result, record.ID)
+	}
+
+	return result
+}
+
+func TestRepo_CountPhoneNumbers(t *testing.T) {
+	repo := newTestRepo(t, testRecords...)
+
+	count := func(filter model.Filter) int {
+		count, err := repo.CountPhoneNumbers(context.Background(), filter)
+		require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+		return count
+	}
+
+	require.Equal(t, 6, count(model.Filter{}))
+	require.Equal(t, 4, count(model.Filter{Codes: []string{"212", "237"}}))
+	require.Equal(t, 2, count(model.Filter{Search: model.Search{Number: "5159"}}))
+	require.Equal(t, 1, count(model.Filter{Search: model.Search{Number: "600", Prefix: true}}))
+	require.Equal(t, 0, count(model.Filter{Search: model.Search{Number: "212", Prefix: true}}))
+
+	// terms match the start of any word in the name, ignoring case
+	require.Equal(t, 2, count(model.Filter{Search: model.Search{Terms: []string{"ann"}}}))
+	require.Equal(t, 1, count(model.Filter{Search: model.Search{Terms: []string{"ann", "le"}}}))
+	require.Equal(t, 1, count(model.Filter{Search: model.Search{Terms: []string{"ramos"}}}))
+	require.Equal(t, 0, count(model.Filter{Search: model.Search{Terms: []string{"nn"}}}))
+
+	// wildcards sent by the client only match themselves
+	require.Equal(t, 1, count(model.Filter{Search: model.Search{Number: "697_"}}))
+	require.Equal(t, 1, count(model.Filter{Search: model.Search{Terms: []string{"100%"}}}))
+	require.Equal(t, 0, count(model.Filter{Search: model.Search{Terms: []string{"a.n"}}}))
+}
+
+func TestRepo_FetchPhoneNumbers(t *testing.T) {
+	repo := newTestRepo(t, testRecords...)
+
+	fetch := func(filter model.Filter, cursor model.Cursor) []model.Record {
+		records, err := repo.FetchPhoneNumbers(context.Background(), filter, cursor)
+		require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+		return records
+	}
+
+	records := fetch(model.Filter{Codes: []string{"237"}}, model.Cursor{Limit: 10})
+	require.Equal(t, []model.Record{testRecords[2], testRecords[3]}, records)
+
+	// pages are read by offset or after a record
+	require.Equal(t, []int64{3, 4}, ids(fetch(model.Filter{}, model.Cursor{Limit: 2, Offset: 2})))
+	require.Equal(t, []int64{5, 6}, ids(fetch(model.Filter{}, model.Cursor{Limit: 2, After: &testRecords[3]})))
+
+	// names are ordered like in SQLite: missing names first, then ignoring case, ties broken by ID
+	byName := []model.SortKey{{Field: "name"}}
+	require.Equal(t, []int64{5, 3, 4, 1, 2, 6}, ids(fetch(model.Filter{}, model.Cursor{Sort: byName, Limit: 10})))
+	require.Equal(t, []int64{1, 2, 6}, ids(fetch(model.Filter{}, model.Cursor{Sort: byName, Limit: 10, After: &testRecords[3]})))
+
+	descending := []model.SortKey{{Field: "phone", Descending: true}}
+	require.Equal(t, []int64{5, 6, 4, 3}, ids(fetch(model.Filter{}, model.Cursor{Sort: descending, Limit: 4})))
+
+	_, err := repo.FetchPhoneNumbers(context.Background(), model.Filter{}, model.Cursor{Sort: []model.SortKey{{Field: "state"}}})
+	require.Error(t, err)
+}
+
+func TestRepo_LastModified(t *testing.T) {
+	repo := newTestRepo(t)
+
+	before, err := repo.LastModified(context.Background())
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.False(t, before.IsZero())
+
+	_, err = repo.db.Exec("INSERT INTO customer (id, name, phone) VALUES (1, 'Ann', '(237) 697151594')")
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	after, err := repo.LastModified(context.Background())
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.True(t, after.After(before), "Expected %v to be after %v", after, before)
+
+	// migrating again keeps the trigger and the time
+	require.NoError(t, migrate(context.Background(), repo.db))
+
+	again, err := repo.LastModified(context.Background())
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.True(t, again.Equal(after))
+}
+
+func TestRepo_Health(t *testing.T) {
+	repo := newTestRepo(t)
+
+	health := repo.Health(context.Background())
+	require.Equal(t, "up", health.Status)
+	require.Contains(t, health.Pools, "postgres")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.FetchPhoneNumbers(ctx, model.Filter{}, model.Cursor{Limit: 1})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRepo_Conformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T, records []model.Record) repository.PhoneNumberRepository {
+		return newTestRepo(t, records...)
+	})
+}
+
+//TestRepo_OpenChecksSchema : the repository only reads the schema, it has to be set up beforehand with migrate
+func TestRepo_OpenChecksSchema(t *testing.T) {
+	db := newTestDB(t, newTestSchema(t))
+	defer func() { _ = db.Close() }()
+
+	_, err := open(db)
+	require.ErrorContains(t, err, "migrate")
+
+	// nothing was created by trying
+	var tables int
+
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM pg_tables WHERE schemaname = current_schema()").Scan(&tables))
+	require.Zero(t, tables)
+
+	require.NoError(t, migrate(context.Background(), db))
+
+	_, err = open(db)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	// a trigger gone missing means Last-Modified can't be trusted anymore
+	_, err = db.Exec("DROP TRIGGER customer_touch ON customer")
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	_, err = open(db)
+	require.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+/*schema : statements which create the customer table and keep track of when it last changed
+They're run by the migrate command rather than on start, so the backend itself needs no more than to read the tables.
+Every statement can be run again. The customer table has the columns of the SQLite database so its rows can be copied
+over as they are.
+*/
+var schema = []string{
+	// migrations running at the same time set up the schema one after the other
+	`SELECT pg_advisory_xact_lock(hashtext('customer_schema'))`,
+	`CREATE TABLE IF NOT EXISTS customer (id BIGINT PRIMARY KEY, name VARCHAR(50), phone VARCHAR(50) NOT NULL)`,
+	// numbers are filtered by the start of the phone number and read in the order of their IDs, or their names
+	`CREATE INDEX IF NOT EXISTS customer_phone ON customer (phone text_pattern_ops)`,
+	// the name index follows the expression names are sorted by, the one lower cased every letter is replaced
+	`DROP INDEX IF EXISTS customer_name`,
+	`CREATE INDEX IF NOT EXISTS customer_name_ascii ON customer ((` + fmt.Sprintf(sortColumns["name"], "name") + `), id)`,
+	`CREATE TABLE IF NOT EXISTS customer_modified (
+		id          BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+		modified_at TIMESTAMPTZ NOT NULL
+	)`,
+	// changes made before the trigger existed can't be dated, they're taken to have happened now
+	`INSERT INTO customer_modified (modified_at) VALUES (now()) ON CONFLICT (id) DO NOTHING`,
+	`CREATE OR REPLACE FUNCTION customer_touch() RETURNS trigger LANGUAGE plpgsql AS $$
+	BEGIN
+		UPDATE customer_modified SET modified_at = clock_timestamp();
+		RETURN NULL;
+	END
+	$$`,
+	// dropping and creating the trigger again would lock the table against readers, so it's only created when missing
+	`DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'customer_touch' AND tgrelid = 'customer'::regclass) THEN
+			CREATE TRIGGER customer_touch AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON customer
+				FOR EACH STATEMENT EXECUTE FUNCTION customer_touch();
+		END IF;
+	END
+	$$`,
+}
+
+//migrate : runs the schema statements in a single transaction
+func migrate(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range schema {
+		if _, err = tx.ExecContext(ctx, statement); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+/*checkSchema : checks that the tables and the trigger the repository relies on exist, without changing anything
+Names are looked up in the search path of the connection, like the queries of the repository do.
+*/
+func checkSchema(ctx context.Context, db *sql.DB) error {
+	var customer, modified, trigger bool
+
+	err := db.QueryRowContext(ctx, `SELECT
+		to_regclass('customer') IS NOT NULL,
+		to_regclass('customer_modified') IS NOT NULL,
+		EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'customer_touch' AND tgrelid = to_regclass('customer'))`,
+	).Scan(&customer, &modified, &trigger)
+
+	if err != nil {
+		return err
+	}
+
+	if !customer || !modified || !trigger {
+		return errors.New("the schema is missing or incomplete, set it up with the `migrate` command")
+	}
+
+	return nil
+}
@@ -0,0 +1,20 @@
+package postgres
+
+import "assessment/infra/db/sqldb"
+
+/*sortColumns : the expression every stored field is ordered by
+Text is compared byte by byte and only the ASCII letters of names are lower cased first, the way SQLite compares them
+with NOCASE, rather than by the collation of the database which orders punctuation and case differently from one locale
+to another. lower() would fold other letters as well, depending on the locale.
+*/
+var sortColumns = sqldb.Columns{
+	"id":    "%s",
+	"name":  `translate(COALESCE(%s::text, ''), ` + asciiUpper + `, ` + asciiLower + `) COLLATE "C"`,
+	"phone": `%s::text COLLATE "C"`,
+}
+
+// asciiUpper, asciiLower : the letters names are folded from and to
+const (
+	asciiUpper = `'ABCDEFGHIJKLMNOPQRSTUVWXYZ'`
+	asciiLower = `'abcdefghijklmnopqrstuvwxyz'`
+)
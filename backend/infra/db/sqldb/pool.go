@@ -0,0 +1,20 @@
+package sqldb
+
+import (
+	"assessment/model"
+	"database/sql"
+)
+
+//PoolStats : The state of a connection pool as reported by the health check
+func PoolStats(db *sql.DB) model.PoolStats {
+	stats := db.Stats()
+
+	return model.PoolStats{
+		MaxOpen:      stats.MaxOpenConnections,
+		Open:         stats.OpenConnections,
+		InUse:        stats.InUse,
+		Idle:         stats.Idle,
+		WaitCount:    stats.WaitCount,
+		WaitDuration: stats.WaitDuration.String(),
+	}
+}
@@ -0,0 +1,114 @@
+package sqldb
+
+import (
+	"assessment/model"
+	"fmt"
+	"strings"
+)
+
+/*Columns : The expression each stored field is ordered by, which differ with how databases compare text
+%s in an expression stands for the field, or for the value the field is compared to when resuming after a record, so
+both sides of a comparison are transformed alike. e.g. lower(%s) compares lower(name) > lower(?)
+*/
+type Columns map[string]string
+
+//column : the expression of a field, or of the value it is compared to
+func (columns Columns) column(field, operand string) (string, error) {
+	expression, ok := columns[field]
+
+	if !ok {
+		return "", fmt.Errorf("records can't be sorted by %q", field)
+	}
+
+	return fmt.Sprintf(expression, operand), nil
+}
+
+//sortValue : the value of a field on a record, as compared by its sort column
+func sortValue(field string, record *model.Record) (interface{}, error) {
+	switch field {
+	case "id":
+		return record.ID, nil
+	case "name":
+		return record.Name, nil
+	case "phone":
+		return record.Phone, nil
+	}
+
+	return nil, fmt.Errorf("records can't be sorted by %q", field)
+}
+
+//withTiebreak : makes the order total by falling back to the ID when every other field is equal
+func withTiebreak(sort []model.SortKey) []model.SortKey {
+	for _, key := range sort {
+		if key.Field == "id" {
+			return sort
+		}
+	}
+
+	return append(append([]model.SortKey{}, sort...), model.SortKey{Field: "id"})
+}
+
+//OrderBy : Builds the terms of the ORDER BY clause for the sort
+func OrderBy(columns Columns, sort []model.SortKey) ([]string, error) {
+	var terms []string
+
+	for _, key := range withTiebreak(sort) {
+		column, err := columns.column(key.Field, key.Field)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if key.Descending {
+			column += " DESC"
+		}
+
+		terms = append(terms, column)
+	}
+
+	return terms, nil
+}
+
+/*After : Builds the condition matching records which come after the given record in the sort order
+For a sort on (name, id) this is: (name > ?) OR (name = ? AND id > ?)
+*/
+func After(columns Columns, sort []model.SortKey, record *model.Record) (string, []interface{}, error) {
+	var (
+		alternatives []string
+		equal        []string
+		args         []interface{}
+		equalArgs    []interface{}
+	)
+
+	for _, key := range withTiebreak(sort) {
+		value, err := sortValue(key.Field, record)
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		column, err := columns.column(key.Field, key.Field)
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		placeholder, _ := columns.column(key.Field, "?")
+
+		operator := ">"
+
+		if key.Descending {
+			operator = "<"
+		}
+
+		// every earlier field has to be equal for this field to decide the order
+		alternative := append(append([]string{}, equal...), fmt.Sprintf("%s %s %s", column, operator, placeholder))
+		alternatives = append(alternatives, "("+strings.Join(alternative, " AND ")+")")
+		args = append(append(args, equalArgs...), value)
+
+		equal = append(equal, column+" = "+placeholder)
+		equalArgs = append(equalArgs, value)
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")", args, nil
+}
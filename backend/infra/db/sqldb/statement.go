@@ -0,0 +1,141 @@
+package sqldb
+
+import (
+	"strconv"
+	"strings"
+)
+
+/*Statement : A SELECT statement built up one clause at a time
+Values are never written into the SQL, every one of them is bound to a ? placeholder, and the arguments are kept in the
+order their placeholders appear in so clauses can be added in any order.
+*/
+type Statement struct {
+	columns    string
+	table      string
+	conditions []string
+	args       []interface{}
+	order      []string
+	limit      int
+	offset     int
+	paged      bool
+}
+
+func Select(table string, columns ...string) *Statement {
+	return &Statement{table: table, columns: strings.Join(columns, ", ")}
+}
+
+//Where : Adds a condition every row has to satisfy, along with the arguments of its placeholders
+func (s *Statement) Where(condition string, args ...interface{}) *Statement {
+	s.conditions = append(s.conditions, condition)
+	s.args = append(s.args, args...)
+
+	return s
+}
+
+//WhereAny : Adds conditions of which a row has to satisfy at least one
+func (s *Statement) WhereAny(conditions []string, args ...interface{}) *Statement {
+	if len(conditions) == 0 {
+		return s
+	}
+
+	return s.Where("("+strings.Join(conditions, " OR ")+")", args...)
+}
+
+func (s *Statement) OrderBy(terms ...string) *Statement {
+	s.order = append(s.order, terms...)
+	return s
+}
+
+func (s *Statement) Page(limit, offset int) *Statement {
+	s.limit, s.offset, s.paged = limit, offset, true
+	return s
+}
+
+/*Build : Returns the SQL of the statement along with its arguments
+The limit and offset are bound as well, so statements which only differ by page share their SQL and prepared statement.
+*/
+func (s *Statement) Build() (string, []interface{}) {
+	var (
+		query strings.Builder
+		args  = append([]interface{}{}, s.args...)
+	)
+
+	query.WriteString("SELECT " + s.columns + " FROM " + s.table)
+
+	if len(s.conditions) > 0 {
+		query.WriteString(" WHERE " + strings.Join(s.conditions, " AND "))
+	}
+
+	if len(s.order) > 0 {
+		query.WriteString(" ORDER BY " + strings.Join(s.order, ", "))
+	}
+
+	if s.paged {
+		query.WriteString(" LIMIT ? OFFSET ?")
+		args = append(args, s.limit, s.offset)
+	}
+
+	return query.String(), args
+}
+
+//Placeholders : Rewrites the ? placeholders of a statement into the ones the database expects
+type Placeholders func(query string) string
+
+//Question : For databases which take ? placeholders as they are, e.g. SQLite and MySQL
+func Question(query string) string {
+	return query
+}
+
+/*Dollar : For databases which number their placeholders, e.g. PostgreSQL
+Question marks in quoted strings and identifiers are left as they are.
+*/
+func Dollar(query string) string {
+	var (
+		result strings.Builder
+		quote  rune // the quote of the string or identifier the query is in, 0 outside of one
+		count  int
+	)
+
+	for _, c := range query {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			count++
+			result.WriteString("$" + strconv.Itoa(count))
+
+			continue
+		}
+
+		result.WriteRune(c)
+	}
+
+	return result.String()
+}
+
+// LikeEscape : the clause following every LIKE whose pattern was escaped with EscapeLike
+const LikeEscape = ` ESCAPE '\'`
+
+// likeEscaper : escapes the characters LIKE treats as wildcards, and the escape character itself
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+/*EscapeLike : Makes a value match itself in a LIKE pattern
+e.g. searching for "50%" matches names containing "50%" rather than every name containing "50"
+*/
+func EscapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+//StartsWith : The pattern of a LIKE matching values which start with the value provided
+func StartsWith(value string) string {
+	return EscapeLike(value) + "%"
+}
+
+//Containing : The pattern of a LIKE matching values which contain the value provided
+func Containing(value string) string {
+	return "%" + EscapeLike(value) + "%"
+}
@@ -0,0 +1,58 @@
+package sqldb
+
+import (
+	"assessment/model"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestStatement_Build(t *testing.T) {
+	query, args := Select("customer", "id", "phone").
+		WhereAny([]string{"phone LIKE ?", "phone LIKE ?"}, "(237)%", "(256)%").
+		Where("id > ?", 4).
+		OrderBy("phone", "id").
+		Page(5, 10).
+		Build()
+
+	require.Equal(t, "SELECT id, phone FROM customer WHERE (phone LIKE ? OR phone LIKE ?) AND id > ? ORDER BY phone, id LIMIT ? OFFSET ?", query)
+	require.Equal(t, []interface{}{"(237)%", "(256)%", 4, 5, 10}, args)
+
+	// no alternatives adds no condition
+	query, args = Select("customer", "COUNT(*)").WhereAny(nil).Build()
+
+	require.Equal(t, "SELECT COUNT(*) FROM customer", query)
+	require.Empty(t, args)
+}
+
+func TestDollar(t *testing.T) {
+	require.Equal(t, "SELECT id FROM customer WHERE phone LIKE $1 ESCAPE '\\' AND id > $2 LIMIT $3 OFFSET $4",
+		Dollar("SELECT id FROM customer WHERE phone LIKE ? ESCAPE '\\' AND id > ? LIMIT ? OFFSET ?"))
+
+	// question marks which aren't placeholders are kept
+	require.Equal(t, `SELECT '?', "a?" FROM customer WHERE id = $1`, Dollar(`SELECT '?', "a?" FROM customer WHERE id = ?`))
+}
+
+func TestEscapeLike(t *testing.T) {
+	require.Equal(t, `50\%`, EscapeLike("50%"))
+	require.Equal(t, `a\_b`, EscapeLike("a_b"))
+	require.Equal(t, `a\\b`, EscapeLike(`a\b`))
+	require.Equal(t, `(237)%`, StartsWith("(237)"))
+	require.Equal(t, `%\_%`, Containing("_"))
+}
+
+func TestAfter(t *testing.T) {
+	columns := Columns{"id": "%s", "name": "lower(%s)"}
+
+	// the value is transformed like the column it's compared to
+	condition, args, err := After(columns, []model.SortKey{{Field: "name", Descending: true}}, &model.Record{ID: 7, Name: "Ann"})
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Equal(t, "((lower(name) < lower(?)) OR (lower(name) = lower(?) AND id > ?))", condition)
+	require.Equal(t, []interface{}{"Ann", "Ann", int64(7)}, args)
+
+	order, err := OrderBy(columns, []model.SortKey{{Field: "name", Descending: true}})
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Equal(t, []string{"lower(name) DESC", "id"}, order)
+
+	_, err = OrderBy(columns, []model.SortKey{{Field: "phone"}})
+	require.Error(t, err)
+}
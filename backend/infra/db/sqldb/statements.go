@@ -0,0 +1,125 @@
+package sqldb
+
+import (
+	"assessment/model"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// maxStatements : the most prepared statements kept, the SQL only varies with the shape of the filters so few are needed
+const maxStatements = 256
+
+/*Statements : Prepared statements kept for reuse, by their SQL
+Preparing is a noticeable part of running the short queries the service makes, and every request runs the same few
+shapes of query. Statements are prepared on the database handle, which prepares them again on every connection they
+end up running on.
+*/
+type Statements struct {
+	mu           sync.RWMutex
+	db           *sql.DB
+	placeholders Placeholders
+	prepared     map[string]*sql.Stmt
+}
+
+func NewStatements(db *sql.DB, placeholders Placeholders) *Statements {
+	return &Statements{db: db, placeholders: placeholders, prepared: make(map[string]*sql.Stmt)}
+}
+
+/*prepare : the prepared statement of the SQL provided, preparing it the first time it's run
+Statements which couldn't be kept aren't cached, the caller has to close those once it's done with them.
+*/
+func (s *Statements) prepare(ctx context.Context, query string) (stmt *sql.Stmt, cached bool, err error) {
+	s.mu.RLock()
+	stmt, ok := s.prepared[query]
+	s.mu.RUnlock()
+
+	if ok {
+		return stmt, true, nil
+	}
+
+	if stmt, err = s.db.PrepareContext(ctx, query); err != nil {
+		return nil, false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// another query prepared the same SQL in the meantime
+	if existing, ok := s.prepared[query]; ok {
+		_ = stmt.Close()
+		return existing, true, nil
+	}
+
+	// an unusual shape of query isn't worth evicting the common ones for, it's prepared every time instead
+	if len(s.prepared) >= maxStatements {
+		return stmt, false, nil
+	}
+
+	s.prepared[query] = stmt
+
+	return stmt, true, nil
+}
+
+//Query : Runs the statement, returning the rows it selects
+func (s *Statements) Query(ctx context.Context, st *Statement) (*sql.Rows, error) {
+	query, args := st.Build()
+
+	stmt, cached, err := s.prepare(ctx, s.placeholders(query))
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the statement is only really closed once the rows it returned are
+	if !cached {
+		defer func() { _ = stmt.Close() }()
+	}
+
+	return stmt.QueryContext(ctx, args...)
+}
+
+/*Each : Runs a statement selecting the id, name and phone of records, handing every record to yield as it's read
+Reading stops early once yield returns false.
+*/
+func (s *Statements) Each(ctx context.Context, st *Statement, yield func(model.Record) bool) error {
+	rows, err := s.Query(ctx, st)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var record model.Record
+
+		if err := rows.Scan(&record.ID, &record.Name, &record.Phone); err != nil {
+			return err
+		}
+
+		if !yield(record) {
+			return nil
+		}
+	}
+
+	return rows.Err()
+}
+
+//Close : Closes every prepared statement
+func (s *Statements) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+
+	for query, stmt := range s.prepared {
+		if closeErr := stmt.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+
+		delete(s.prepared, query)
+	}
+
+	return err
+}
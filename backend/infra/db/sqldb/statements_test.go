@@ -0,0 +1,41 @@
+//go:build cgo
+
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestStatements_Query(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	// every connection to :memory: is a database of its own
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`CREATE TABLE customer (id INTEGER PRIMARY KEY, phone TEXT);
+		INSERT INTO customer VALUES (1, '(237) 697151594'), (2, '(256) 7734127498')`)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	statements := NewStatements(db, Question)
+	defer func() { _ = statements.Close() }()
+
+	for offset := 0; offset < 2; offset++ {
+		rows, err := statements.Query(context.Background(), Select("customer", "id").OrderBy("id").Page(1, offset))
+		require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+		var id int64
+
+		require.True(t, rows.Next())
+		require.NoError(t, rows.Scan(&id))
+		require.Equal(t, int64(offset+1), id)
+		require.NoError(t, rows.Close())
+	}
+
+	// statements which only differ by page share their prepared statement
+	require.Len(t, statements.prepared, 1)
+}
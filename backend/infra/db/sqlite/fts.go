@@ -0,0 +1,107 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// the table and triggers making up the full text index, the index is only complete when they all exist
+var fullTextObjects = []interface{}{"customer_fts", "customer_fts_insert", "customer_fts_delete", "customer_fts_update"}
+
+// statements which create a full text index over the customers' names and keep it in sync with the customer table
+var fullTextSetup = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS customer_fts USING fts5(name, content='customer', content_rowid='id')`,
+	`CREATE TRIGGER IF NOT EXISTS customer_fts_insert AFTER INSERT ON customer BEGIN
+		INSERT INTO customer_fts(rowid, name) VALUES (new.id, new.name);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS customer_fts_delete AFTER DELETE ON customer BEGIN
+		INSERT INTO customer_fts(customer_fts, rowid, name) VALUES ('delete', old.id, old.name);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS customer_fts_update AFTER UPDATE ON customer BEGIN
+		INSERT INTO customer_fts(customer_fts, rowid, name) VALUES ('delete', old.id, old.name);
+		INSERT INTO customer_fts(rowid, name) VALUES (new.id, new.name);
+	END`,
+	// rows written before the index existed (or while a trigger was missing) are picked up by a rebuild
+	`INSERT INTO customer_fts(customer_fts) VALUES ('rebuild')`,
+}
+
+/*hasFullTextIndex : checks that the FTS5 index used for searching names exists and can be read, without writing anything
+FTS5 is only compiled into the sqlite driver when building with `-tags sqlite_fts5`, without it reading the index fails
+and names are searched with LIKE instead.
+*/
+func hasFullTextIndex(db *sql.DB) (bool, error) {
+	complete, err := fullTextIndexComplete(db)
+
+	if err != nil || !complete {
+		return false, err
+	}
+
+	err = db.QueryRow("SELECT rowid FROM customer_fts LIMIT 1").Scan(new(int64))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+
+	return err == nil, err
+}
+
+//rowQuerier : a database or a transaction
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+//fullTextIndexComplete : whether the table and every trigger of the index exist
+func fullTextIndexComplete(q rowQuerier) (bool, error) {
+	var found int
+
+	err := q.QueryRow(
+		"SELECT count(*) FROM sqlite_master WHERE name IN (?, ?, ?, ?)", fullTextObjects...,
+	).Scan(&found)
+
+	return found == len(fullTextObjects), err
+}
+
+/*createFullTextIndex : creates the FTS5 index used for searching names, unless it's already there
+Building the index reads every customer, so it's only done when some of it was missing. Once the index exists its
+triggers keep it in sync with every write, there's nothing to catch up on.
+Returns whether the index was built.
+*/
+func createFullTextIndex(db *sql.DB) (bool, error) {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return false, err
+	}
+
+	complete, err := fullTextIndexComplete(tx)
+
+	if err != nil || complete {
+		_ = tx.Rollback()
+		return false, err
+	}
+
+	for _, statement := range fullTextSetup {
+		if _, err = tx.Exec(statement); err != nil {
+			_ = tx.Rollback()
+			return false, err
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+//fullTextQuery : builds an FTS5 query matching names with a word starting with every term
+func fullTextQuery(terms []string) string {
+	var phrases []string
+
+	for _, term := range terms {
+		// quoting the term stops it from being interpreted as FTS5 syntax
+		phrases = append(phrases, fmt.Sprintf(`"%s"*`, strings.ReplaceAll(term, `"`, `""`)))
+	}
+
+	return strings.Join(phrases, " ")
+}
@@ -0,0 +1,6 @@
+//go:build cgo && sqlite_fts5
+
+package sqlite
+
+// withFTS5 : the driver is built with FTS5, so the tests search names through the full text index
+const withFTS5 = true
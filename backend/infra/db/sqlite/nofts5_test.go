@@ -0,0 +1,6 @@
+//go:build cgo && !sqlite_fts5
+
+package sqlite
+
+// withFTS5 : the driver is built without FTS5, so the tests search names with LIKE
+const withFTS5 = false
@@ -0,0 +1,93 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"assessment/config"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/mattn/go-sqlite3"
+	"net/url"
+	"time"
+)
+
+//connector : opens connections to the database with the options of a pool, without registering a driver for every pool
+type connector struct {
+	dsn    string
+	driver *sqlite3.SQLiteDriver
+}
+
+func (c connector) Connect(_ context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c connector) Driver() driver.Driver {
+	return c.driver
+}
+
+/*dsn : builds the data source name of the database with the options provided
+Writers take the write lock when their transaction begins, so two of them never both read and then fail to upgrade their
+lock. Readers are opened read only, and the journal mode is only set by writers since changing it is a write.
+*/
+func dsn(fileName string, options config.SQLite, write bool) string {
+	params := url.Values{}
+
+	if options.BusyTimeout > 0 {
+		params.Set("_busy_timeout", fmt.Sprint(options.BusyTimeout.Milliseconds()))
+	}
+
+	params.Set("_foreign_keys", fmt.Sprint(options.ForeignKeys))
+
+	if write {
+		params.Set("_txlock", "immediate")
+
+		if options.JournalMode != "" {
+			params.Set("_journal_mode", options.JournalMode)
+		}
+	} else {
+		params.Set("mode", "ro")
+	}
+
+	if options.Immutable {
+		params.Set("immutable", "1")
+	}
+
+	return "file:" + fileName + "?" + params.Encode()
+}
+
+//openPool : opens a pool of connections to the database and checks that it can be reached
+func openPool(fileName string, options config.SQLite, write bool) (*sql.DB, error) {
+	d := &sqlite3.SQLiteDriver{}
+
+	// the page cache has no DSN option of its own
+	if options.CacheSize != 0 {
+		d.ConnectHook = func(conn *sqlite3.SQLiteConn) error {
+			_, err := conn.Exec(fmt.Sprintf("PRAGMA cache_size = -%d", options.CacheSize), nil)
+			return err
+		}
+	}
+
+	db := sql.OpenDB(connector{dsn: dsn(fileName, options, write), driver: d})
+
+	size := options.ReadConnections
+
+	if write {
+		size = options.WriteConnections
+	}
+
+	db.SetMaxOpenConns(size)
+	db.SetMaxIdleConns(size)
+
+	// connections are opened lazily, a missing or unreadable file would otherwise only show on the first request
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
@@ -0,0 +1,86 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"assessment/config"
+	"context"
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDSN(t *testing.T) {
+	options := config.SQLite{JournalMode: "WAL", BusyTimeout: 2 * time.Second, ForeignKeys: true, Immutable: true}
+
+	params := func(dsn string) url.Values {
+		require.True(t, strings.HasPrefix(dsn, "file:test.db?"), dsn)
+
+		values, err := url.ParseQuery(strings.TrimPrefix(dsn, "file:test.db?"))
+		require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+		return values
+	}
+
+	write := params(dsn("test.db", options, true))
+	require.Equal(t, "2000", write.Get("_busy_timeout"))
+	require.Equal(t, "true", write.Get("_foreign_keys"))
+	require.Equal(t, "WAL", write.Get("_journal_mode"))
+	require.Equal(t, "immediate", write.Get("_txlock"))
+	require.Equal(t, "1", write.Get("immutable"))
+
+	// readers can't change the journal mode
+	read := params(dsn("test.db", options, false))
+	require.Equal(t, "ro", read.Get("mode"))
+	require.Empty(t, read.Get("_journal_mode"))
+}
+
+func TestOpenPool(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "test.db")
+	options := config.DefaultSQLite
+	options.JournalMode = "WAL"
+	options.CacheSize = 4096
+
+	writer, err := openPool(fileName, options, true)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	defer func() { _ = writer.Close() }()
+
+	_, err = writer.Exec(`CREATE TABLE customer (id INTEGER PRIMARY KEY, name TEXT, phone TEXT)`)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	var (
+		mode      string
+		cacheSize int
+	)
+
+	require.NoError(t, writer.QueryRow("PRAGMA journal_mode").Scan(&mode))
+	require.NoError(t, writer.QueryRow("PRAGMA cache_size").Scan(&cacheSize))
+	require.Equal(t, "wal", mode)
+	require.Equal(t, -4096, cacheSize)
+	require.Equal(t, 1, writer.Stats().MaxOpenConnections)
+
+	reader, err := openPool(fileName, options, false)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	defer func() { _ = reader.Close() }()
+
+	require.Equal(t, options.ReadConnections, reader.Stats().MaxOpenConnections)
+
+	// readers are read only
+	_, err = reader.Exec(`INSERT INTO customer VALUES (1, 'Ann', '(237) 697151594')`)
+	require.Error(t, err)
+
+	repo := &Repo{reader: reader, writer: writer}
+	health := repo.Health(context.Background())
+
+	require.Equal(t, "up", health.Status)
+	require.Empty(t, health.Error)
+	require.Contains(t, health.Pools, "read")
+	require.Contains(t, health.Pools, "write")
+
+	// a database which doesn't exist can't be opened for reading
+	_, err = openPool(filepath.Join(t.TempDir(), "missing.db"), options, false)
+	require.Error(t, err)
+}
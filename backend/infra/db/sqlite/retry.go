@@ -0,0 +1,51 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"github.com/mattn/go-sqlite3"
+	"time"
+)
+
+// busyBackoff : how long the first retry of a busy query waits, every retry after it waits twice as long as the last
+const busyBackoff = 10 * time.Millisecond
+
+/*busy : checks whether the error means another connection held a lock the query needed
+The busy timeout already has SQLite wait for the lock, but some conflicts are reported straight away, e.g. a reader of a
+WAL database whose snapshot was overwritten, and those succeed when the query is simply run again.
+*/
+func busy(err error) bool {
+	var sqliteErr sqlite3.Error
+
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+
+	return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+}
+
+//retry : runs the query, running it again with an exponential backoff for as long as it fails because the database is busy
+func (repo *Repo) retry(ctx context.Context, query func() error) error {
+	wait := busyBackoff
+
+	for attempt := 0; ; attempt++ {
+		err := query()
+
+		if err == nil || !busy(err) || attempt >= repo.busyRetries {
+			return err
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		wait *= 2
+	}
+}
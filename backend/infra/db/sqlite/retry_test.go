@@ -0,0 +1,57 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRepo_Retry(t *testing.T) {
+	repo := &Repo{busyRetries: 3}
+	busyErr := sqlite3.Error{Code: sqlite3.ErrBusy}
+
+	// busy queries are run again until they succeed
+	attempts := 0
+
+	err := repo.retry(context.Background(), func() error {
+		if attempts++; attempts < 3 {
+			return busyErr
+		}
+
+		return nil
+	})
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Equal(t, 3, attempts)
+
+	// or until the retries run out
+	attempts = 0
+
+	err = repo.retry(context.Background(), func() error {
+		attempts++
+		return busyErr
+	})
+	require.Equal(t, busyErr, err)
+	require.Equal(t, 4, attempts)
+
+	// other errors aren't retried
+	attempts = 0
+	otherErr := errors.New("no such table: customer")
+
+	err = repo.retry(context.Background(), func() error {
+		attempts++
+		return otherErr
+	})
+	require.Equal(t, otherErr, err)
+	require.Equal(t, 1, attempts)
+
+	// nor are queries of requests which are done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = repo.retry(ctx, func() error { return busyErr })
+	require.Equal(t, context.Canceled, err)
+}
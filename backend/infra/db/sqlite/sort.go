@@ -0,0 +1,12 @@
+//go:build cgo
+
+package sqlite
+
+import "assessment/infra/db/sqldb"
+
+// sortColumns : the expression every stored field is ordered by, names are compared without regard to case
+var sortColumns = sqldb.Columns{
+	"id":    "%s",
+	"name":  "COALESCE(%s, '') COLLATE NOCASE",
+	"phone": "%s",
+}
@@ -1,89 +1,374 @@
+//go:build cgo
+
 package sqlite
 
 import (
-	"assessment/apperror"
 	"assessment/config"
+	"assessment/infra/db/sqldb"
+	"assessment/model"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	_ "github.com/mattn/go-sqlite3"
+	"log"
+	"os"
+	"time"
 )
 
+/*Repo : Reads the phone numbers from an SQLite database
+The backend only ever reads, so it opens nothing but a read only pool and leaves the file as it is. The write pool is
+only opened by one-off commands such as CreateSearchIndex, limited to the one writer at a time SQLite allows anyway.
+*/
 type Repo struct {
-	db *sql.DB
+	reader      *sql.DB
+	writer      *sql.DB // nil unless the database was opened for writing
+	statements  *sqldb.Statements
+	fileName    string
+	fullText    bool // whether names can be searched through the FTS5 index
+	busyRetries int
 }
 
 //NewSqliteClient : Creates a new client for interfacing with the db
 func NewSqliteClient() (*Repo, error) {
 	conf := config.FetchConfig()
 
-	connectionInfo := fmt.Sprintf(fmt.Sprintf("%s", conf.DatabaseFileName))
+	return open(conf.DatabaseFileName, conf.SQLite, false)
+}
+
+/*CreateSearchIndex : Creates the FTS5 index names are searched with in the database at DB_FILE_NAME
+It's a one-off migration and the only thing which writes to the database, the backend opens it read only so starting it
+doesn't change the file. An index which already exists is left as it is.
+*/
+func CreateSearchIndex() error {
+	conf := config.FetchConfig()
+
+	if conf.SQLite.ReadOnly || conf.SQLite.Immutable {
+		return errors.New("the search index can't be created while SQLITE_READ_ONLY or SQLITE_IMMUTABLE is set")
+	}
 
-	db, err := sql.Open("sqlite3", connectionInfo)
+	repo, err := open(conf.DatabaseFileName, conf.SQLite, true)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return &Repo{db}, nil
+	defer func() { _ = repo.Close() }()
+
+	return repo.createSearchIndex(context.Background())
 }
 
-//FetchPaginatedPhoneNumbers : Fetches paginated phone numbers from the database
-func (repo *Repo) FetchPaginatedPhoneNumbers(offset, limit int) ([]string, error) {
-	var (
-		result []string
-	)
+/*open : opens the read pool of the database file with the options provided, and the write pool along with it when asked
+Only opening the writer can change the file, it's what switches it to the configured journal mode.
+*/
+func open(fileName string, options config.SQLite, write bool) (*Repo, error) {
+	repo := &Repo{fileName: fileName, busyRetries: options.BusyRetries}
+
+	var err error
+
+	// the writer goes first so the readers are opened in the journal mode it switched the file to
+	if write {
+		if repo.writer, err = openPool(fileName, options, true); err != nil {
+			return nil, fmt.Errorf("opening %s for writing: %w", fileName, err)
+		}
+	}
+
+	if repo.reader, err = openPool(fileName, options, false); err != nil {
+		_ = repo.Close()
+		return nil, fmt.Errorf("opening %s for reading: %w", fileName, err)
+	}
+
+	repo.statements = sqldb.NewStatements(repo.reader, sqldb.Question)
+	repo.fullText = repo.setUpFullTextSearch()
+
+	return repo, nil
+}
+
+/*setUpFullTextSearch : checks for the index names are searched with, returning whether it can be used
+Nothing is written, the index is created beforehand with CreateSearchIndex.
+*/
+func (repo *Repo) setUpFullTextSearch() bool {
+	ok, err := hasFullTextIndex(repo.reader)
+
+	if err != nil {
+		log.Printf("Full text search is unavailable, names will be searched without it: %v\n", err)
+		return false
+	}
+
+	if !ok {
+		log.Println("There's no full text index, names will be searched without it until it's created with `create-search-index`")
+	}
+
+	return ok
+}
+
+//createSearchIndex : creates the index names are searched with when it's missing, and starts searching with it
+func (repo *Repo) createSearchIndex(ctx context.Context) error {
+	if repo.writer == nil {
+		return errors.New("the search index can't be created in a database which wasn't opened for writing")
+	}
+
+	var built bool
+
+	err := repo.retry(ctx, func() (err error) {
+		built, err = createFullTextIndex(repo.writer)
+		return err
+	})
 
-	query := fmt.Sprintf("SELECT phone FROM customer LIMIT %d, %d", offset, limit)
+	if err != nil {
+		return err
+	}
+
+	if built {
+		log.Printf("Created the full text index of %s\n", repo.fileName)
+	} else {
+		log.Printf("The full text index of %s already exists\n", repo.fileName)
+	}
+
+	repo.fullText = true
+
+	return nil
+}
+
+/*FetchPhoneNumbers : Fetches phone numbers matching the filter from the database, starting at the cursor
+Only the country code can be checked by the database, the state of a number is computed by the service.
+*/
+func (repo *Repo) FetchPhoneNumbers(ctx context.Context, filter model.Filter, cursor model.Cursor) ([]model.Record, error) {
+	var result []model.Record
 
-	rows, err := repo.db.Query(query)
+	st, err := repo.records(filter, cursor)
 
 	if err != nil {
 		return nil, err
 	}
 
-	defer func() { _ = rows.Close() }()
+	st.Page(cursor.Limit, cursor.Offset)
 
-	for rows.Next() {
-		var phone string
+	err = repo.retry(ctx, func() error {
+		result = nil
 
-		if err := rows.Scan(&phone); err != nil {
-			return nil, err
-		}
+		return repo.statements.Each(ctx, st, func(record model.Record) bool {
+			result = append(result, record)
+			return true
+		})
+	})
 
-		result = append(result, phone)
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
 }
 
-// FetchPaginatedPhoneNumbersByCode : Fetches paginated phone numbers using the country code provided
-func (repo *Repo) FetchPaginatedPhoneNumbersByCode(code string, offset, limit int) ([]string, error) {
+/*ScanPhoneNumbers : Hands the phone numbers matching the filter to yield as they're read, starting at the cursor
+A busy database is only retried until the first record has been handed over, yield never sees a record twice.
+*/
+func (repo *Repo) ScanPhoneNumbers(ctx context.Context, filter model.Filter, cursor model.Cursor, yield func(model.Record) bool) error {
+	st, err := repo.records(filter, cursor)
+
+	if err != nil {
+		return err
+	}
+
+	// SQLite reads to the end with a negative limit, an offset can't be used without a limit
+	limit := cursor.Limit
+
+	if limit <= 0 {
+		limit = -1
+	}
+
+	st.Page(limit, cursor.Offset)
+
 	var (
-		result []string
+		yielded bool
+		scanErr error
 	)
 
-	query := fmt.Sprintf("SELECT phone FROM customer WHERE phone LIKE '(%s)%%' LIMIT %d, %d", code, offset, limit)
+	err = repo.retry(ctx, func() error {
+		err := repo.statements.Each(ctx, st, func(record model.Record) bool {
+			yielded = true
+			return yield(record)
+		})
+
+		// an error once the scan is under way is passed on as it is
+		if err != nil && yielded {
+			scanErr = err
+			return nil
+		}
+
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return scanErr
+}
+
+//records : builds the statement reading the records matching the filter in the order of the cursor, without paging it
+func (repo *Repo) records(filter model.Filter, cursor model.Cursor) (*sqldb.Statement, error) {
+	st := repo.filter(sqldb.Select("customer", "id", "COALESCE(name, '')", "phone"), filter)
+
+	// resume right after the record the cursor points at
+	if cursor.After != nil {
+		condition, args, err := sqldb.After(sortColumns, cursor.Sort, cursor.After)
+
+		if err != nil {
+			return nil, err
+		}
+
+		st.Where(condition, args...)
+	}
 
-	rows, err := repo.db.Query(query)
+	order, err := sqldb.OrderBy(sortColumns, cursor.Sort)
 
 	if err != nil {
 		return nil, err
 	}
 
-	defer func() { _ = rows.Close() }()
+	return st.OrderBy(order...), nil
+}
+
+//CountPhoneNumbers : Counts the phone numbers in the database matching the filter, ignoring the state
+func (repo *Repo) CountPhoneNumbers(ctx context.Context, filter model.Filter) (int, error) {
+	var count int
+
+	st := repo.filter(sqldb.Select("customer", "COUNT(*)"), filter)
+
+	err := repo.retry(ctx, func() error {
+		rows, err := repo.statements.Query(ctx, st)
 
-	for rows.Next() {
-		var phone string
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = rows.Close() }()
 
-		if err := rows.Scan(&phone); err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				err = apperror.NotFound
+		if rows.Next() {
+			if err := rows.Scan(&count); err != nil {
+				return err
 			}
-			return nil, err
 		}
 
-		result = append(result, phone)
+		return rows.Err()
+	})
+
+	return count, err
+}
+
+/*Health : Checks that every open pool can reach the database, along with the state of their connections
+The database is down when any of the pools can't reach it.
+*/
+func (repo *Repo) Health(ctx context.Context) model.Health {
+	health := model.Health{Status: "up", Pools: make(map[string]model.PoolStats)}
+
+	for name, db := range map[string]*sql.DB{"read": repo.reader, "write": repo.writer} {
+		if db == nil {
+			continue
+		}
+
+		if err := db.PingContext(ctx); err != nil && health.Error == "" {
+			health.Status, health.Error = "down", fmt.Sprintf("%s pool: %v", name, err)
+		}
+
+		health.Pools[name] = sqldb.PoolStats(db)
 	}
-	return result, nil
+
+	return health
+}
+
+//Close : Releases the prepared statements and the pools
+func (repo *Repo) Close() error {
+	var errs []error
+
+	if repo.statements != nil {
+		errs = append(errs, repo.statements.Close())
+	}
+
+	for _, db := range []*sql.DB{repo.reader, repo.writer} {
+		if db != nil {
+			errs = append(errs, db.Close())
+		}
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*LastModified : The last time the data in the database changed, the zero time when it isn't stored in a file
+Writes land in the write-ahead log before they reach the database file when it is enabled, so both files are checked.
+*/
+func (repo *Repo) LastModified(ctx context.Context) (time.Time, error) {
+	var lastModified time.Time
+
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, err
+	}
+
+	for _, name := range []string{repo.fileName, repo.fileName + "-wal"} {
+		info, err := os.Stat(name)
+
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if info.ModTime().After(lastModified) {
+			lastModified = info.ModTime()
+		}
+	}
+
+	return lastModified, nil
+}
+
+/*filter : adds the conditions a row has to satisfy to match the filter to the statement
+Every value sent by the client is bound to a placeholder, and escaped when it's part of a LIKE pattern.
+*/
+func (repo *Repo) filter(st *sqldb.Statement, filter model.Filter) *sqldb.Statement {
+	// a number matches when its bracketed digits start with any of the included codes, as a number written (1242) is
+	// validated as one of code 1, followed by the national number 242...
+	var (
+		matches []string
+		codes   []interface{}
+	)
+
+	for _, code := range filter.Codes {
+		matches = append(matches, "phone LIKE ?"+sqldb.LikeEscape)
+		codes = append(codes, sqldb.StartsWith("("+code))
+	}
+
+	st.WhereAny(matches, codes...)
+
+	// the national number is everything after the bracketed country code
+	if filter.Search.Number != "" {
+		pattern := sqldb.Containing(filter.Search.Number)
+
+		if filter.Search.Prefix {
+			pattern = sqldb.StartsWith(filter.Search.Number)
+		}
+
+		st.Where("ltrim(substr(phone, instr(phone, ')') + 1)) LIKE ?"+sqldb.LikeEscape, pattern)
+	}
+
+	if len(filter.Search.Terms) > 0 {
+		if repo.fullText {
+			st.Where("id IN (SELECT rowid FROM customer_fts WHERE customer_fts MATCH ?)", fullTextQuery(filter.Search.Terms))
+		} else {
+			// without the index, a term matches the start of the name or anything following a space
+			for _, term := range filter.Search.Terms {
+				st.Where("(' ' || lower(name)) LIKE ?"+sqldb.LikeEscape, "% "+sqldb.StartsWith(term))
+			}
+		}
+	}
+
+	return st
 }
@@ -0,0 +1,206 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"assessment/config"
+	"assessment/infra/db/sqldb"
+	"assessment/model"
+	"assessment/repository"
+	"assessment/repository/repotest"
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+/*newTestRepo : a repository over a temporary database file holding the records
+The table is created like the one in sample.db, with missing names stored as NULL. Names are searched through the full
+text index when the driver is built with FTS5, which the tests only do with -tags sqlite_fts5.
+*/
+func newTestRepo(t *testing.T, records []model.Record) *Repo {
+	t.Helper()
+
+	repo := openTestRepo(t, newTestDatabase(t, records), true)
+	err := repo.createSearchIndex(context.Background())
+
+	if !withFTS5 {
+		require.ErrorContains(t, err, "no such module: fts5")
+		return repo
+	}
+
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.True(t, repo.fullText)
+
+	return repo
+}
+
+//newTestDatabase : a temporary database file holding the records, without a full text index
+func newTestDatabase(t *testing.T, records []model.Record) string {
+	t.Helper()
+
+	fileName := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := sql.Open("sqlite3", fileName)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	_, err = db.Exec(`CREATE TABLE customer (id int, name varchar(50), phone varchar(50))`)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	for _, record := range records {
+		_, err = db.Exec("INSERT INTO customer (id, name, phone) VALUES (?, NULLIF(?, ''), ?)", record.ID, record.Name, record.Phone)
+		require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	}
+
+	require.NoError(t, db.Close())
+
+	return fileName
+}
+
+func openTestRepo(t *testing.T, fileName string, write bool) *Repo {
+	t.Helper()
+
+	repo, err := open(fileName, config.DefaultSQLite, write)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	t.Cleanup(func() { _ = repo.Close() })
+
+	return repo
+}
+
+func TestRepo_Conformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T, records []model.Record) repository.PhoneNumberRepository {
+		return newTestRepo(t, records)
+	})
+}
+
+//TestRepo_ConformanceWithoutFullText : names are searched with LIKE when the FTS5 index is unavailable, with the same results
+func TestRepo_ConformanceWithoutFullText(t *testing.T) {
+	repotest.Run(t, func(t *testing.T, records []model.Record) repository.PhoneNumberRepository {
+		repo := newTestRepo(t, records)
+		repo.fullText = false
+
+		return repo
+	})
+}
+
+func TestRepo_FilterEscapesWildcards(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	// every connection to :memory: is a database of its own
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`CREATE TABLE customer (id INTEGER PRIMARY KEY, name TEXT, phone TEXT);
+		INSERT INTO customer VALUES (1, 'Ann 100% Sure', '(237) 697151594'), (2, 'Ann Lee', '(237) 697_51594')`)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	repo := &Repo{reader: db, statements: sqldb.NewStatements(db, sqldb.Question)}
+	defer func() { _ = repo.Close() }()
+
+	count := func(filter model.Filter) int {
+		count, err := repo.CountPhoneNumbers(context.Background(), filter)
+		require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+		return count
+	}
+
+	// wildcards sent by the client only match themselves
+	require.Equal(t, 1, count(model.Filter{Search: model.Search{Number: "697_"}}))
+	require.Equal(t, 1, count(model.Filter{Search: model.Search{Terms: []string{"100%"}}}))
+	require.Equal(t, 2, count(model.Filter{Codes: []string{"237"}}))
+
+	records, err := repo.FetchPhoneNumbers(context.Background(), model.Filter{Codes: []string{"237"}}, model.Cursor{Limit: 1})
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Len(t, records, 1)
+
+	records, err = repo.FetchPhoneNumbers(context.Background(), model.Filter{Codes: []string{"237"}}, model.Cursor{Limit: 1, Offset: 1})
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Equal(t, int64(2), records[0].ID)
+}
+
+//TestRepo_OpenLeavesFileAlone : the backend only reads, so opening the database doesn't change a byte of it
+func TestRepo_OpenLeavesFileAlone(t *testing.T) {
+	fileName := newTestDatabase(t, repotest.Records)
+
+	before, err := os.ReadFile(fileName)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	options := config.DefaultSQLite
+	options.JournalMode = "WAL"
+
+	repo, err := open(fileName, options, false)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Nil(t, repo.writer)
+
+	_, err = repo.CountPhoneNumbers(context.Background(), model.Filter{})
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.NoError(t, repo.Close())
+
+	after, err := os.ReadFile(fileName)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Equal(t, before, after)
+
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		_, err = os.Stat(fileName + suffix)
+		require.True(t, os.IsNotExist(err), "Expected no %s file\nGot: %v\n", suffix, err)
+	}
+
+	// only a repository opened for writing can create the search index
+	readOnly := openTestRepo(t, fileName, false)
+	require.Error(t, readOnly.createSearchIndex(context.Background()))
+}
+
+func TestRepo_OpenDoesNotCreateSearchIndex(t *testing.T) {
+	repo := openTestRepo(t, newTestDatabase(t, repotest.Records), true)
+
+	require.False(t, repo.fullText)
+
+	complete, err := fullTextIndexComplete(repo.reader)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.False(t, complete)
+
+	var objects int
+
+	require.NoError(t, repo.reader.QueryRow("SELECT count(*) FROM sqlite_master WHERE name LIKE 'customer_fts%'").Scan(&objects))
+	require.Zero(t, objects)
+}
+
+func TestRepo_CreateSearchIndex(t *testing.T) {
+	if !withFTS5 {
+		t.Skip("the driver is built without FTS5, run with -tags sqlite_fts5")
+	}
+
+	fileName := newTestDatabase(t, repotest.Records)
+	repo := openTestRepo(t, fileName, true)
+
+	built, err := createFullTextIndex(repo.writer)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.True(t, built)
+
+	// the index is there now, so it's neither created nor rebuilt again
+	built, err = createFullTextIndex(repo.writer)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.False(t, built)
+
+	// the rows written before the index existed were picked up when it was built, later ones by its triggers
+	_, err = repo.writer.Exec("INSERT INTO customer (id, name, phone) VALUES (9, 'Annabel Quist', '(237) 697151594')")
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	// a repository opened afterwards searches with the index without writing it
+	reopened := openTestRepo(t, fileName, false)
+	require.True(t, reopened.fullText)
+
+	count, err := reopened.CountPhoneNumbers(context.Background(), model.Filter{Search: model.Search{Terms: []string{"ann"}}})
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Equal(t, 4, count)
+
+	// a missing trigger means writes may have been missed, so the index is made whole and rebuilt
+	_, err = repo.writer.Exec("DROP TRIGGER customer_fts_update")
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	built, err = createFullTextIndex(repo.writer)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.True(t, built)
+}
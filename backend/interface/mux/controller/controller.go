@@ -1,51 +1,76 @@
 package controller
 
 import (
+	"assessment/config"
 	"assessment/interface/mux/helper"
-	"assessment/model"
 	"assessment/service"
+	"encoding/json"
+	"log"
 	"net/http"
 )
 
 type Controller struct {
 	numberService *service.NumberService
+	envelope      helper.Envelope // how results are wrapped for the API version this controller serves
 }
 
 func NewNumberController(numberService *service.NumberService) *Controller {
-	return &Controller{numberService: numberService}
+	return &Controller{numberService: numberService, envelope: helper.V1}
+}
+
+//WithEnvelope : Creates a copy of the controller which wraps its results in another envelope, for other API versions
+func (controller *Controller) WithEnvelope(envelope helper.Envelope) *Controller {
+	return &Controller{numberService: controller.numberService, envelope: envelope}
 }
 
 func (controller *Controller) FetchAllPhoneNumbers(w http.ResponseWriter, r *http.Request) {
-	queries := r.URL.Query()
+	controller.list(w, r, config.FetchConfig().PhoneNumbersPageSize, config.FetchConfig().PhoneNumbersCache)
+}
 
-	limit := queries.Get("limit")
-	page := queries.Get("page")
-	country := queries.Get("country")
-	state := queries.Get("state")
+//Search : Searches phone numbers and customer names, the search is required here but otherwise works like FetchAllPhoneNumbers
+func (controller *Controller) Search(w http.ResponseWriter, r *http.Request) {
+	controller.list(w, r, config.FetchConfig().SearchPageSize, config.FetchConfig().SearchCache)
+}
 
-	var (
-		result model.Result
-		err    error
-	)
+/*Health : Reports whether the phone numbers can be read, with a 503 when they can't so load balancers stop sending traffic
+The state of the connection pools is included so a pool which is too small shows up as queries waiting for connections.
+*/
+func (controller *Controller) Health(w http.ResponseWriter, r *http.Request) {
+	health := controller.numberService.Health(r.Context())
+
+	status := http.StatusOK
+
+	if health.Status != "up" {
+		status = http.StatusServiceUnavailable
+	}
 
-	switch {
-	case country == "" && state == "":
-		result, err = controller.numberService.FetchPhoneNumbers(page, limit)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
 
-	case country != "" && state == "":
-		result, err = controller.numberService.FilterByCountry(country, page, limit)
+	if err := json.NewEncoder(w).Encode(health); err != nil {
+		log.Println(err)
+	}
+}
 
-	case country == "" && state != "":
-		result, err = controller.numberService.FilterByState(state, page, limit)
+//list : Lists the phone numbers matching the query sent, in pages of the size and with the caching configured for the endpoint
+func (controller *Controller) list(w http.ResponseWriter, r *http.Request, pageSize config.PageSize, cache config.Cache) {
+	query, err := service.ParseQuery(r.URL.Query(), pageSize)
 
-	case country != "" && page != "":
-		result, err = controller.numberService.FilterByCountryAndState(country, state, page, limit)
+	if err != nil {
+		helper.ReturnFailure(w, err)
+		return
 	}
 
+	// the request context is canceled when the client disconnects, which stops the query
+	result, err := controller.numberService.Query(r.Context(), query)
+
 	if err != nil {
 		helper.ReturnFailure(w, err)
 		return
 	}
 
-	helper.ReturnSuccess(w, result)
+	result.Links = helper.Links(r, result.Meta, config.FetchConfig().TrustedProxies)
+
+	helper.ReturnSuccess(w, r, result, controller.envelope, cache)
 }
@@ -1,17 +1,24 @@
 package controller_test
 
 import (
+	"assessment/apperror"
 	"assessment/interface/mux/controller"
 	"assessment/interface/mux/router"
+	"assessment/model"
+	"assessment/repository"
 	repoMock "assessment/repository/mock"
 	"assessment/service"
+	"context"
+	"encoding/json"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 var rt *mux.Router
@@ -23,17 +30,11 @@ type testSuite struct {
 
 func (t *testSuite) SetupSuite() {
 	mockRepo := new(repoMock.PhoneNumberRepository)
-	mockRepo.On("FetchPaginatedPhoneNumbers", 0, 11).
-		Return([]string{
-			"(237) 697151594",
-			"(212) 654642448",
-			"(258) 042423566",
-			"(256) 7734127498",
-		}, nil)
-	mockRepo.On("FetchPaginatedPhoneNumbers", 0, 6).
-		Return([]string{}, nil)
-	mockRepo.On("FetchPaginatedPhoneNumbersByCode", "237", 0, 11).
-		Return([]string{
+	mockRepo.On("LastModified", mock.Anything).Return(time.Time{}, nil)
+	mockRepo.On("ScanPhoneNumbers", mock.Anything, model.Filter{Countries: []string{"cameroon"}, Codes: []string{"237"}, States: []string{"OK"}}, mock.Anything, mock.Anything).
+		Run(scan("(237) 23456789")).Return(nil)
+	mockRepo.On("FetchPhoneNumbers", mock.Anything, model.Filter{Countries: []string{"cameroon"}, Codes: []string{"237"}}, model.Cursor{Limit: 11}).
+		Return(records(
 			"(237) 23456789",
 			"(237) 23456789",
 			"(237) 23456789",
@@ -45,15 +46,28 @@ func (t *testSuite) SetupSuite() {
 			"(237) 23456789",
 			"(237) 23456789",
 			"(237) 23456789",
-		}, nil)
+		), nil)
+	mockRepo.On("FetchPhoneNumbers", mock.Anything, model.Filter{}, model.Cursor{Limit: 6}).
+		Return(records(), nil)
 
-	mockRepo.On("FetchPaginatedPhoneNumbers", mock.Anything, 11).
-		Return([]string{
+	mockRepo.On("FetchPhoneNumbers", mock.Anything, mock.Anything, mock.Anything).
+		Return(records(
 			"(237) 697151594",
 			"(212) 654642448",
 			"(258) 042423566",
 			"(256) 7734127498",
-		}, nil)
+		), nil)
+
+	// filtering by state and sorting by computed fields scan the repository instead
+	mockRepo.On("ScanPhoneNumbers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(scan(
+			"(237) 697151594",
+			"(212) 654642448",
+			"(258) 042423566",
+			"(256) 7734127498",
+		)).Return(nil)
+
+	mockRepo.On("CountPhoneNumbers", mock.Anything, mock.Anything).Return(4, nil)
 
 	validator := service.NewValidator()
 
@@ -69,26 +83,32 @@ func TestServiceSuite(t *testing.T) {
 }
 func (t *testSuite) TestController_FetchAllPhoneNumbers() {
 
-	req := httptest.NewRequest(http.MethodGet, "/phone-numbers?limit=10&page=1", nil)
+	req := httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?limit=10&page=1", nil)
 
 	response := executeRequest(req)
 
 	checkResponseCode(t.T(), http.StatusOK, response.Code)
+	require.Equal(t.T(),
+		`<http://example.com/v1/phone-numbers?limit=10&page=1>; rel="self", `+
+			`<http://example.com/v1/phone-numbers?limit=10&page=1>; rel="first", `+
+			`<http://example.com/v1/phone-numbers?limit=10&page=1>; rel="last"`,
+		response.Header().Get("Link"),
+	)
 
-	req = httptest.NewRequest(http.MethodGet, "/phone-numbers", nil)
+	req = httptest.NewRequest(http.MethodGet, "/v1/phone-numbers", nil)
 
 	response = executeRequest(req)
 
 	checkResponseCode(t.T(), http.StatusOK, response.Code)
 
-	req = httptest.NewRequest(http.MethodGet, "/phone-numbers?limit=-1&page=1", nil)
+	req = httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?limit=-1&page=1", nil)
 	response = executeRequest(req)
 
 	checkResponseCode(t.T(), http.StatusBadRequest, response.Code)
 }
 
 func (t *testSuite) TestController_FetchPhoneNumberByCountry() {
-	req := httptest.NewRequest(http.MethodGet, "/phone-numbers?limit=10&page=1&country=cameroon", nil)
+	req := httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?limit=10&page=1&country=cameroon", nil)
 
 	response := executeRequest(req)
 
@@ -96,19 +116,19 @@ func (t *testSuite) TestController_FetchPhoneNumberByCountry() {
 }
 
 func (t *testSuite) TestController_FetchPhoneNumbersByState() {
-	req := httptest.NewRequest(http.MethodGet, "/phone-numbers?limit=10&page=1&state=NOK", nil)
+	req := httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?limit=10&page=1&state=NOK", nil)
 
 	response := executeRequest(req)
 
 	checkResponseCode(t.T(), http.StatusOK, response.Code)
 
-	req = httptest.NewRequest(http.MethodGet, "/phone-numbers?limit=10&page=1&state=OK", nil)
+	req = httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?limit=10&page=1&state=OK", nil)
 
 	response = executeRequest(req)
 
 	checkResponseCode(t.T(), http.StatusOK, response.Code)
 
-	req = httptest.NewRequest(http.MethodGet, "/phone-numbers?limit=10&page=1&state=VALID", nil)
+	req = httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?limit=10&page=1&state=VALID", nil)
 
 	response = executeRequest(req)
 
@@ -117,25 +137,318 @@ func (t *testSuite) TestController_FetchPhoneNumbersByState() {
 }
 
 func (t *testSuite) TestController_FetchPhoneNumbersByCountryAndState() {
-	req := httptest.NewRequest(http.MethodGet, "/phone-numbers?limit=10&page=1&country=nigeria&state=OK", nil)
+	req := httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?limit=10&page=1&country=atlantis&state=OK", nil)
 
 	response := executeRequest(req)
 
 	checkResponseCode(t.T(), http.StatusNotFound, response.Code)
 
-	req = httptest.NewRequest(http.MethodGet, "/phone-numbers?limit=10&page=1&country=nigeria&state=NOK", nil)
+	req = httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?limit=10&page=1&country=atlantis&state=NOK", nil)
 
 	response = executeRequest(req)
 
 	checkResponseCode(t.T(), http.StatusNotFound, response.Code)
 
-	req = httptest.NewRequest(http.MethodGet, "/phone-numbers?limit=10&page=1&country=nigeria&state=INVALID", nil)
+	req = httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?limit=10&page=1&country=atlantis&state=INVALID", nil)
+
+	response = executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusBadRequest, response.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?limit=10&country=cameroon&state=OK", nil)
+
+	response = executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusOK, response.Code)
+	require.Contains(t.T(), response.Body.String(), `"phoneNumber":"23456789"`)
+}
+
+func (t *testSuite) TestController_Sort() {
+	req := httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?sort=country,-name", nil)
+
+	response := executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusOK, response.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?sort=-countryCode", nil)
+
+	response = executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusBadRequest, response.Code)
+	require.Contains(t.T(), response.Body.String(), `"name":"sort"`)
+	require.Contains(t.T(), response.Body.String(), `got \"-countryCode\"`)
+}
+
+func (t *testSuite) TestController_Fields() {
+	req := httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?limit=10&fields=phoneNumber,state", nil)
+
+	response := executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusOK, response.Code)
+	require.Contains(t.T(), response.Body.String(), `{"phoneNumber":"697151594","state":"OK"}`)
+	require.NotContains(t.T(), response.Body.String(), `"country"`)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?fields=phoneNumber,phone", nil)
+
+	response = executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusBadRequest, response.Code)
+	require.Contains(t.T(), response.Body.String(), `"name":"fields"`)
+	require.Contains(t.T(), response.Body.String(), `got \"phone\"`)
+}
+
+func (t *testSuite) TestController_Search() {
+	req := httptest.NewRequest(http.MethodGet, "/v1/search?q=697", nil)
+
+	response := executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusOK, response.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/search", nil)
+
+	response = executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusBadRequest, response.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?q=walid", nil)
+
+	response = executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusOK, response.Code)
+}
+
+func (t *testSuite) TestController_InvalidParameters() {
+	req := httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?limit=1000&page=0&state=OK,VALID&country=", nil)
+
+	response := executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusBadRequest, response.Code)
+
+	var body struct {
+		Message    string                      `json:"message"`
+		Parameters []apperror.InvalidParameter `json:"parameters"`
+	}
+
+	require.NoError(t.T(), json.Unmarshal(response.Body.Bytes(), &body))
+	require.Equal(t.T(), []apperror.InvalidParameter{
+		{Name: "state", In: "query", Reason: `has to be one of OK, NOK, got "VALID"`},
+		{Name: "page", In: "query", Reason: "has to be 1 or greater"},
+		{Name: "limit", In: "query", Reason: "can't be greater than 100"},
+	}, body.Parameters)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?limit=ten&page=1&page=2", nil)
+
+	response = executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusBadRequest, response.Code)
+	require.NoError(t.T(), json.Unmarshal(response.Body.Bytes(), &body))
+	require.Equal(t.T(), []apperror.InvalidParameter{
+		{Name: "page", In: "query", Reason: "can't be sent more than once"},
+		{Name: "limit", In: "query", Reason: "has to be a whole number"},
+	}, body.Parameters)
+
+	// the search is required on /search
+	req = httptest.NewRequest(http.MethodGet, "/v1/search?q=", nil)
+
+	response = executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusBadRequest, response.Code)
+	require.NoError(t.T(), json.Unmarshal(response.Body.Bytes(), &body))
+	require.Equal(t.T(), []apperror.InvalidParameter{{Name: "q", In: "query", Reason: "is required"}}, body.Parameters)
+
+	// a search with nothing but punctuation, or lower case states, are reported the same way
+	req = httptest.NewRequest(http.MethodGet, "/v1/search?q=()&state=ok", nil)
+
+	response = executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusBadRequest, response.Code)
+	require.NoError(t.T(), json.Unmarshal(response.Body.Bytes(), &body))
+	require.Equal(t.T(), []apperror.InvalidParameter{
+		{Name: "state", In: "query", Reason: `has to be one of OK, NOK, got "ok"`},
+		{Name: "q", In: "query", Reason: `has to match [\p{L}\p{N}], got "()"`},
+	}, body.Parameters)
+
+	// page and offset can't be used together, which the document can't say, so the service reports it
+	req = httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?page=1&offset=5", nil)
+
+	response = executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusBadRequest, response.Code)
+	require.NoError(t.T(), json.Unmarshal(response.Body.Bytes(), &body))
+	require.Equal(t.T(), []apperror.InvalidParameter{{Name: "offset", In: "query", Reason: "can't be used with page"}}, body.Parameters)
+
+	// deprecated routes are validated the same way and still say so
+	req = httptest.NewRequest(http.MethodGet, "/phone-numbers?offset=-1", nil)
+
+	response = executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusBadRequest, response.Code)
+	require.NotEmpty(t.T(), response.Header().Get("Deprecation"))
+	require.NoError(t.T(), json.Unmarshal(response.Body.Bytes(), &body))
+	require.Equal(t.T(), []apperror.InvalidParameter{{Name: "offset", In: "query", Reason: "has to be 0 or greater"}}, body.Parameters)
+}
+
+func (t *testSuite) TestController_ETag() {
+	req := httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?limit=10", nil)
+
+	response := executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusOK, response.Code)
+
+	etag := response.Header().Get("ETag")
+	require.NotEmpty(t.T(), etag)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?limit=10", nil)
+	req.Header.Set("If-None-Match", etag)
+
+	response = executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusNotModified, response.Code)
+	require.Empty(t.T(), response.Body.String())
+
+	// another page is another body
+	req = httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?limit=9", nil)
+	req.Header.Set("If-None-Match", etag)
+
+	response = executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusOK, response.Code)
+
+	// a body too small to be compressed keeps its strong tag, in the 304 standing in for it as well
+	req = httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?limit=1&fields=id", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	response = executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusOK, response.Code)
+	require.Empty(t.T(), response.Header().Get("Content-Encoding"))
+
+	etag = response.Header().Get("ETag")
+	require.False(t.T(), strings.HasPrefix(etag, "W/"), etag)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/phone-numbers?limit=1&fields=id", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("If-None-Match", etag)
+
+	response = executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusNotModified, response.Code)
+	require.Equal(t.T(), etag, response.Header().Get("ETag"))
+}
+
+func (t *testSuite) TestController_LegacyRoutes() {
+	req := httptest.NewRequest(http.MethodGet, "/phone-numbers?limit=10&page=1", nil)
+
+	response := executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusOK, response.Code)
+	require.NotEmpty(t.T(), response.Header().Get("Deprecation"))
+	require.NotEmpty(t.T(), response.Header().Get("Sunset"))
+	require.Equal(t.T(), []string{
+		`</v1/phone-numbers?limit=10&page=1>; rel="successor-version"`,
+		`<http://example.com/phone-numbers?limit=10&page=1>; rel="self", ` +
+			`<http://example.com/phone-numbers?limit=10&page=1>; rel="first", ` +
+			`<http://example.com/phone-numbers?limit=10&page=1>; rel="last"`,
+	}, response.Header().Values("Link"))
+
+	req = httptest.NewRequest(http.MethodGet, "/search?q=697", nil)
+
+	response = executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusOK, response.Code)
+	require.Equal(t.T(), `</v1/search?q=697>; rel="successor-version"`, response.Header().Get("Link"))
+
+	// the versioned routes aren't deprecated
+	req = httptest.NewRequest(http.MethodGet, "/v1/phone-numbers", nil)
+
+	response = executeRequest(req)
+
+	checkResponseCode(t.T(), http.StatusOK, response.Code)
+	require.Empty(t.T(), response.Header().Get("Deprecation"))
+
+	req = httptest.NewRequest(http.MethodGet, "/v2/phone-numbers", nil)
 
 	response = executeRequest(req)
 
 	checkResponseCode(t.T(), http.StatusNotFound, response.Code)
 }
 
+func TestController_QueryTimeout(t *testing.T) {
+	mockRepo := new(repoMock.PhoneNumberRepository)
+	mockRepo.On("LastModified", mock.Anything).Return(time.Time{}, nil)
+	mockRepo.On("FetchPhoneNumbers", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { <-args.Get(0).(context.Context).Done() }).
+		Return(nil, context.DeadlineExceeded)
+
+	svc := service.NewNumberService(service.NewValidator(), mockRepo, service.WithQueryTimeout(10*time.Millisecond))
+
+	rr := httptest.NewRecorder()
+	router.InitRouter(controller.NewNumberController(svc)).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/phone-numbers", nil))
+
+	checkResponseCode(t, http.StatusGatewayTimeout, rr.Code)
+
+	var failure map[string]interface{}
+
+	err := json.Unmarshal(rr.Body.Bytes(), &failure)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Equal(t, apperror.Timeout.Message, failure["message"])
+}
+
+//downRepository : a repository whose database can't be reached
+type downRepository struct {
+	*repoMock.PhoneNumberRepository
+}
+
+func (downRepository) Health(context.Context) model.Health {
+	return model.Health{Status: "down", Error: "read pool: unable to open database file"}
+}
+
+func TestController_Health(t *testing.T) {
+	health := func(repo repository.PhoneNumberRepository) *httptest.ResponseRecorder {
+		svc := service.NewNumberService(service.NewValidator(), repo)
+
+		rr := httptest.NewRecorder()
+		router.InitRouter(controller.NewNumberController(svc)).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		return rr
+	}
+
+	// repositories which can't check their health are always up
+	rr := health(new(repoMock.PhoneNumberRepository))
+
+	checkResponseCode(t, http.StatusOK, rr.Code)
+	require.JSONEq(t, `{"status":"up"}`, rr.Body.String())
+	require.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+	rr = health(downRepository{new(repoMock.PhoneNumberRepository)})
+
+	checkResponseCode(t, http.StatusServiceUnavailable, rr.Code)
+	require.JSONEq(t, `{"status":"down","error":"read pool: unable to open database file"}`, rr.Body.String())
+}
+
+func records(phones ...string) []model.Record {
+	result := make([]model.Record, 0, len(phones))
+
+	for index, phone := range phones {
+		result = append(result, model.Record{ID: int64(index + 1), Phone: phone})
+	}
+
+	return result
+}
+
+// scan : hands the records of the phones provided to the yield function of a scan
+func scan(phones ...string) func(mock.Arguments) {
+	return func(args mock.Arguments) {
+		yield := args.Get(3).(func(model.Record) bool)
+
+		for _, record := range records(phones...) {
+			if !yield(record) {
+				return
+			}
+		}
+	}
+}
+
 func executeRequest(req *http.Request) *httptest.ResponseRecorder {
 	rr := httptest.NewRecorder()
 
@@ -0,0 +1,65 @@
+package helper
+
+import (
+	"assessment/config"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+)
+
+//ETag : a strong entity tag for a response body, equal bodies always get the same tag
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:18]) + `"`
+}
+
+/*notModified : sets the caching headers of a response and checks whether the client's copy is still current
+If-None-Match takes precedence over If-Modified-Since when both are sent, as RFC 9110 requires.
+*/
+func notModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time, cache config.Cache) bool {
+	w.Header().Set("ETag", etag)
+
+	if cache.Control != "" {
+		w.Header().Set("Cache-Control", cache.Control)
+	}
+
+	// HTTP dates only have a precision of a second
+	lastModified = lastModified.UTC().Truncate(time.Second)
+	sendLastModified := cache.LastModified && !lastModified.IsZero()
+
+	if sendLastModified {
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, etag)
+	}
+
+	if ifModifiedSince := r.Header.Get("If-Modified-Since"); ifModifiedSince != "" && sendLastModified {
+		since, err := http.ParseTime(ifModifiedSince)
+
+		return err == nil && !lastModified.After(since)
+	}
+
+	return false
+}
+
+//etagMatches : checks an If-None-Match header against the tag, using the weak comparison RFC 9110 asks for
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
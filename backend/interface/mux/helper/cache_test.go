@@ -0,0 +1,84 @@
+package helper
+
+import (
+	"assessment/config"
+	"assessment/model"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestReturnSuccess_ConditionalRequests(t *testing.T) {
+	lastModified := time.Date(2026, 10, 19, 12, 30, 15, 500, time.UTC)
+	result := model.Result{Data: []model.Data{{ID: 1, PhoneNumber: "697151594"}}, LastModified: lastModified}
+	cache := config.Cache{Control: "no-cache", LastModified: true}
+
+	serve := func(headers map[string]string, result model.Result, cache config.Cache) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/phone-numbers", nil)
+
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+
+		rr := httptest.NewRecorder()
+		ReturnSuccess(rr, req, result, V1, cache)
+
+		return rr
+	}
+
+	rr := serve(nil, result, cache)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "no-cache", rr.Header().Get("Cache-Control"))
+	require.Equal(t, "Mon, 19 Oct 2026 12:30:15 GMT", rr.Header().Get("Last-Modified"))
+
+	body := rr.Body.Bytes()
+	etag := rr.Header().Get("ETag")
+	require.Equal(t, ETag(body), etag)
+
+	var testCases = []struct {
+		headers  map[string]string
+		expected int
+	}{
+		{map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{map[string]string{"If-None-Match": `"other", ` + etag}, http.StatusNotModified},
+		{map[string]string{"If-None-Match": "W/" + etag}, http.StatusNotModified},
+		{map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{map[string]string{"If-None-Match": `"other"`}, http.StatusOK},
+		{map[string]string{"If-Modified-Since": "Mon, 19 Oct 2026 12:30:15 GMT"}, http.StatusNotModified},
+		{map[string]string{"If-Modified-Since": "Mon, 19 Oct 2026 12:30:14 GMT"}, http.StatusOK},
+		{map[string]string{"If-Modified-Since": "yesterday"}, http.StatusOK},
+		// If-None-Match decides on its own when both are sent
+		{map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": "Mon, 19 Oct 2026 12:30:15 GMT"}, http.StatusOK},
+	}
+
+	for _, tCase := range testCases {
+		rr = serve(tCase.headers, result, cache)
+
+		require.Equal(t, tCase.expected, rr.Code, "%v", tCase.headers)
+		require.Equal(t, etag, rr.Header().Get("ETag"))
+
+		if tCase.expected == http.StatusNotModified {
+			require.Empty(t, rr.Body.String())
+			require.Equal(t, strconv.Itoa(len(body)), rr.Header().Get("Content-Length"))
+		}
+	}
+
+	// a different body gets a different tag
+	result.Data[0].ID = 2
+	rr = serve(map[string]string{"If-None-Match": etag}, result, cache)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NotEqual(t, etag, rr.Header().Get("ETag"))
+
+	// routes which aren't configured to send them don't get the caching headers, or answer If-Modified-Since
+	rr = serve(map[string]string{"If-Modified-Since": "Mon, 19 Oct 2026 12:30:15 GMT"}, result, config.Cache{})
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Empty(t, rr.Header().Get("Cache-Control"))
+	require.Empty(t, rr.Header().Get("Last-Modified"))
+	require.NotEmpty(t, rr.Header().Get("ETag"))
+}
@@ -0,0 +1,24 @@
+package helper
+
+import "assessment/model"
+
+/*Envelope : wraps a result in the body sent to the client
+Every version of the API can shape its responses differently while sharing the controllers and the service layer, a new
+version only needs its own envelope.
+*/
+type Envelope func(result model.Result) interface{}
+
+//V1 : the envelope of the first version of the API, {"message": "success", "result": {"data", "meta", "links"}}
+func V1(result model.Result) interface{} {
+	var body interface{} = result
+
+	// leave out the fields the client didn't ask for
+	if len(result.Fields) > 0 {
+		body = sparse(result)
+	}
+
+	return struct {
+		Message string      `json:"message"`
+		Data    interface{} `json:"result"`
+	}{"success", body}
+}
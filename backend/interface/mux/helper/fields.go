@@ -0,0 +1,54 @@
+package helper
+
+import (
+	"assessment/model"
+	"reflect"
+	"strings"
+)
+
+// dataFields : the index of every field in model.Data, by its JSON name
+var dataFields = func() map[string]int {
+	fields := make(map[string]int)
+
+	t := reflect.TypeOf(model.Data{})
+
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		fields[name] = i
+	}
+
+	return fields
+}()
+
+//sparseResult : the result envelope used when the client only asked for some of the fields
+type sparseResult struct {
+	Data  []map[string]interface{} `json:"data"`
+	Meta  model.Meta               `json:"meta"`
+	Links *model.Links             `json:"links,omitempty"`
+}
+
+//sparse : keeps only the requested fields of every row, fields which are left out when empty stay left out
+func sparse(result model.Result) sparseResult {
+	rows := make([]map[string]interface{}, 0, len(result.Data))
+
+	for _, d := range result.Data {
+		value := reflect.ValueOf(d)
+		row := make(map[string]interface{}, len(result.Fields))
+
+		for _, field := range result.Fields {
+			index, ok := dataFields[field]
+
+			if !ok {
+				continue
+			}
+
+			if f := value.Field(index); f.Kind() != reflect.Ptr || !f.IsNil() {
+				row[field] = f.Interface()
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return sparseResult{Data: rows, Meta: result.Meta, Links: result.Links}
+}
@@ -0,0 +1,140 @@
+package helper
+
+import (
+	"assessment/config"
+	"assessment/model"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+/*Links : builds the URLs of the pages around the current result
+The URLs are built from the incoming request so every filter the client sent is kept, only the page changes.
+Clients paginating with offset and limit rather than page get links which use the offset.
+When the backend runs behind one of the trusted proxies, the X-Forwarded-Proto and X-Forwarded-Host headers are used so
+the links point at the proxy rather than at the backend. Anyone else sending them is ignored, otherwise a client could
+have links to another host cached and served to everyone.
+*/
+func Links(r *http.Request, meta model.Meta, proxies config.TrustedProxies) *model.Links {
+	current, err := strconv.Atoi(meta.CurrentPage)
+
+	if err != nil {
+		return nil
+	}
+
+	forwarded := proxies.Trusts(r.RemoteAddr)
+
+	base := url.URL{
+		Scheme: scheme(r, forwarded),
+		Host:   host(r, forwarded),
+		Path:   r.URL.Path,
+	}
+
+	query := r.URL.Query()
+
+	// the current page may not begin on a page boundary, so the offsets of the other pages are worked out from its own
+	link := func(page, offset int) string {
+		if query.Get("offset") != "" {
+			if offset < 0 {
+				offset = 0
+			}
+
+			query.Set("offset", strconv.Itoa(offset))
+		} else {
+			query.Set("page", strconv.Itoa(page))
+		}
+
+		u := base
+		u.RawQuery = query.Encode()
+
+		return u.String()
+	}
+
+	links := &model.Links{
+		Self:  link(current, meta.Offset),
+		First: link(1, 0),
+	}
+
+	if meta.Prev {
+		links.Prev = link(current-1, meta.Offset-meta.Limit)
+	}
+
+	if meta.Next {
+		links.Next = link(current+1, meta.Offset+meta.Limit)
+	}
+
+	if meta.LastPage > 0 {
+		links.Last = link(meta.LastPage, lastOffset(meta))
+	}
+
+	return links
+}
+
+/*lastOffset : the offset of the last page which still has results, stepping by limit from the current offset
+An offset which isn't a multiple of the limit is kept that way, so with 40 results and a limit of 3 the last page of
+offset=1 is offset=37, which holds the last 3 results rather than none.
+*/
+func lastOffset(meta model.Meta) int {
+	if meta.Count <= 0 || meta.Limit <= 0 {
+		return 0
+	}
+
+	// the number of whole pages between the current offset and the last result, rounded down even past the end
+	pages := (meta.Count - 1 - meta.Offset) / meta.Limit
+
+	if meta.Count-1 < meta.Offset && (meta.Count-1-meta.Offset)%meta.Limit != 0 {
+		pages--
+	}
+
+	if offset := meta.Offset + pages*meta.Limit; offset > 0 {
+		return offset
+	}
+
+	return 0
+}
+
+//LinkHeader : formats the links as an RFC 8288 Link header
+func LinkHeader(links *model.Links) string {
+	var values []string
+
+	for _, link := range []struct{ rel, url string }{
+		{"self", links.Self},
+		{"first", links.First},
+		{"prev", links.Prev},
+		{"next", links.Next},
+		{"last", links.Last},
+	} {
+		if link.url != "" {
+			values = append(values, fmt.Sprintf(`<%s>; rel="%s"`, link.url, link.rel))
+		}
+	}
+
+	return strings.Join(values, ", ")
+}
+
+func scheme(r *http.Request, forwarded bool) string {
+	if proto := firstForwarded(r.Header.Get("X-Forwarded-Proto")); forwarded && (proto == "http" || proto == "https") {
+		return proto
+	}
+
+	if r.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
+func host(r *http.Request, forwarded bool) string {
+	if forwardedHost := firstForwarded(r.Header.Get("X-Forwarded-Host")); forwarded && forwardedHost != "" {
+		return forwardedHost
+	}
+
+	return r.Host
+}
+
+//firstForwarded : every proxy a request passes through appends to the header, the first value is the one the client used
+func firstForwarded(value string) string {
+	return strings.TrimSpace(strings.Split(value, ",")[0])
+}
@@ -0,0 +1,109 @@
+package helper
+
+import (
+	"assessment/config"
+	"assessment/model"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestLinks(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://localhost:9942/phone-numbers?country=cameroon&state=OK&page=2&limit=3", nil)
+
+	links := Links(req, model.Meta{CurrentPage: "2", Next: true, Prev: true, LastPage: 4}, nil)
+
+	require.Equal(t, &model.Links{
+		Self:  "http://localhost:9942/phone-numbers?country=cameroon&limit=3&page=2&state=OK",
+		First: "http://localhost:9942/phone-numbers?country=cameroon&limit=3&page=1&state=OK",
+		Prev:  "http://localhost:9942/phone-numbers?country=cameroon&limit=3&page=1&state=OK",
+		Next:  "http://localhost:9942/phone-numbers?country=cameroon&limit=3&page=3&state=OK",
+		Last:  "http://localhost:9942/phone-numbers?country=cameroon&limit=3&page=4&state=OK",
+	}, links)
+
+	require.Equal(t,
+		`<http://localhost:9942/phone-numbers?country=cameroon&limit=3&page=2&state=OK>; rel="self", `+
+			`<http://localhost:9942/phone-numbers?country=cameroon&limit=3&page=1&state=OK>; rel="first", `+
+			`<http://localhost:9942/phone-numbers?country=cameroon&limit=3&page=1&state=OK>; rel="prev", `+
+			`<http://localhost:9942/phone-numbers?country=cameroon&limit=3&page=3&state=OK>; rel="next", `+
+			`<http://localhost:9942/phone-numbers?country=cameroon&limit=3&page=4&state=OK>; rel="last"`,
+		LinkHeader(links),
+	)
+}
+
+func TestLinks_BehindProxy(t *testing.T) {
+	_, proxyNetwork, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	proxies := config.TrustedProxies{proxyNetwork}
+
+	req := httptest.NewRequest(http.MethodGet, "http://backend:9942/phone-numbers", nil)
+	req.RemoteAddr = "10.1.2.3:40000"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "api.example.com, proxy.internal")
+
+	// pages which don't exist or aren't known are left out
+	links := Links(req, model.Meta{CurrentPage: "1"}, proxies)
+
+	require.Equal(t, &model.Links{
+		Self:  "https://api.example.com/phone-numbers?page=1",
+		First: "https://api.example.com/phone-numbers?page=1",
+	}, links)
+
+	// the same headers sent by anyone else point the links at the backend, whether proxies are configured or not
+	req.RemoteAddr = "203.0.113.7:40000"
+
+	for _, proxies := range []config.TrustedProxies{proxies, nil} {
+		links = Links(req, model.Meta{CurrentPage: "1"}, proxies)
+
+		require.Equal(t, &model.Links{
+			Self:  "http://backend:9942/phone-numbers?page=1",
+			First: "http://backend:9942/phone-numbers?page=1",
+		}, links)
+	}
+}
+
+func TestLinks_WithOffset(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://localhost:9942/phone-numbers?offset=7&limit=5", nil)
+
+	links := Links(req, model.Meta{CurrentPage: "2", Offset: 7, Limit: 5, Next: true, Prev: true, LastPage: 4, Count: 18}, nil)
+
+	require.Equal(t, &model.Links{
+		Self:  "http://localhost:9942/phone-numbers?limit=5&offset=7",
+		First: "http://localhost:9942/phone-numbers?limit=5&offset=0",
+		Prev:  "http://localhost:9942/phone-numbers?limit=5&offset=2",
+		Next:  "http://localhost:9942/phone-numbers?limit=5&offset=12",
+		Last:  "http://localhost:9942/phone-numbers?limit=5&offset=17",
+	}, links)
+}
+
+func TestLinks_WithUnalignedOffset(t *testing.T) {
+	// 40 results read 3 at a time
+	for _, test := range []struct {
+		offset int
+		last   string
+	}{
+		{0, "39"},
+		{1, "37"},
+		{37, "37"},
+		{38, "38"},
+		{39, "39"},
+		{41, "38"},
+		{45, "39"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost:9942/phone-numbers?limit=3&offset="+strconv.Itoa(test.offset), nil)
+
+		links := Links(req, model.Meta{CurrentPage: "1", Offset: test.offset, Limit: 3, LastPage: 14, Count: 40}, nil)
+
+		require.Equal(t, "http://localhost:9942/phone-numbers?limit=3&offset="+test.last, links.Last, "offset=%d", test.offset)
+	}
+
+	// nothing to page through
+	req := httptest.NewRequest(http.MethodGet, "http://localhost:9942/phone-numbers?limit=3&offset=5", nil)
+
+	require.Equal(t, 0, lastOffset(model.Meta{Offset: 5, Limit: 3}))
+	require.Empty(t, Links(req, model.Meta{CurrentPage: "1", Offset: 5, Limit: 3}, nil).Last)
+}
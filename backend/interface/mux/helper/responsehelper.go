@@ -2,12 +2,21 @@ package helper
 
 import (
 	"assessment/apperror"
+	"assessment/config"
 	"assessment/model"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
 )
 
+//Failure : the body of every failed response
+type Failure struct {
+	Message    string                      `json:"message"`
+	Parameters []apperror.InvalidParameter `json:"parameters,omitempty"` // every parameter which failed validation
+}
+
 //ReturnFailure : Return Failure response in the event of an error
 func ReturnFailure(w http.ResponseWriter, err error) {
 	w.Header().Set("Content-Type", "application/json")
@@ -15,14 +24,33 @@ func ReturnFailure(w http.ResponseWriter, err error) {
 	switch err {
 	case apperror.BadRequest:
 		w.WriteHeader(apperror.BadRequest.Status)
-		err2 = json.NewEncoder(w).Encode(map[string]string{"message": "invalid request received"})
+		err2 = json.NewEncoder(w).Encode(Failure{Message: "invalid request received"})
 	case apperror.NotFound:
 		w.WriteHeader(apperror.NotFound.Status)
-		err2 = json.NewEncoder(w).Encode(map[string]string{"message": "the requested resource was not found on this server"})
+		err2 = json.NewEncoder(w).Encode(Failure{Message: "the requested resource was not found on this server"})
 	case apperror.ServerError:
 		w.WriteHeader(apperror.ServerError.Status)
-		err2 = json.NewEncoder(w).Encode(map[string]string{"message": "an error occurred while processing that request"})
+		err2 = json.NewEncoder(w).Encode(Failure{Message: "an error occurred while processing that request"})
+	default:
+		var (
+			appErr        apperror.AppError
+			validationErr apperror.ValidationError
+		)
+
+		// every invalid parameter is reported at once so the client can fix them together
+		if errors.As(err, &validationErr) {
+			w.WriteHeader(apperror.BadRequest.Status)
+			err2 = json.NewEncoder(w).Encode(Failure{Message: "invalid request parameters", Parameters: validationErr.Parameters})
+			break
+		}
 
+		// errors which aren't application errors are never shown to the client
+		if !errors.As(err, &appErr) {
+			appErr = apperror.ServerError
+		}
+
+		w.WriteHeader(appErr.Status)
+		err2 = json.NewEncoder(w).Encode(Failure{Message: appErr.Message})
 	}
 
 	if err2 != nil {
@@ -30,17 +58,41 @@ func ReturnFailure(w http.ResponseWriter, err error) {
 	}
 }
 
-//ReturnSuccess : Return success response on completion of an operation
-func ReturnSuccess(w http.ResponseWriter, data model.Result) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+/*ReturnSuccess : Return success response on completion of an operation, wrapped in the envelope of the API version called
+Every response carries a strong ETag computed from its body, and requests which already hold the same body (If-None-Match)
+or which hold a copy at least as recent as the data (If-Modified-Since) get an empty 304 Not Modified instead.
+*/
+func ReturnSuccess(w http.ResponseWriter, r *http.Request, data model.Result, envelope Envelope, cache config.Cache) {
+	body, err := json.Marshal(envelope(data))
 
-	resp := struct {
-		Message string       `json:"message"`
-		Data    model.Result `json:"result"`
-	}{"success", data}
-	err := json.NewEncoder(w).Encode(resp)
 	if err != nil {
 		log.Printf("Error encoding JSON: %v", err)
+		ReturnFailure(w, apperror.ServerError)
+		return
+	}
+
+	// kept identical to what json.Encoder writes
+	body = append(body, '\n')
+
+	w.Header().Set("Content-Type", "application/json")
+
+	// added rather than set, deprecated routes already point at their successor in a Link header
+	if data.Links != nil {
+		w.Header().Add("Link", LinkHeader(data.Links))
+	}
+
+	if notModified(w, r, ETag(body), data.LastModified, cache) {
+		// the length of the body the 304 stands in for, so the response is tagged like that body would have been once
+		// compressed. RFC 9110 allows it, net/http doesn't send it anyway
+		w.Header().Del("Content-Type")
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	if _, err = w.Write(body); err != nil {
+		log.Printf("Error writing response: %v", err)
 	}
 }
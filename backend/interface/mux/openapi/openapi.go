@@ -0,0 +1,116 @@
+package openapi
+
+// Version : the version of the OpenAPI specification documents are written in
+const Version = "3.1.0"
+
+type (
+	//Document : An OpenAPI document describing the API
+	Document struct {
+		OpenAPI    string               `json:"openapi"`
+		Info       Info                 `json:"info"`
+		Paths      map[string]*PathItem `json:"paths"`
+		Components Components           `json:"components"`
+	}
+
+	//Info : Metadata about the API
+	Info struct {
+		Title       string `json:"title"`
+		Description string `json:"description,omitempty"`
+		Version     string `json:"version"`
+	}
+
+	//PathItem : The operations available on a path, only GET is served
+	PathItem struct {
+		Get *Operation `json:"get,omitempty"`
+	}
+
+	//Operation : A single route of the API
+	Operation struct {
+		OperationID string              `json:"operationId"`
+		Summary     string              `json:"summary"`
+		Description string              `json:"description,omitempty"`
+		Tags        []string            `json:"tags,omitempty"`
+		Deprecated  bool                `json:"deprecated,omitempty"`
+		Parameters  []Parameter         `json:"parameters,omitempty"`
+		RequestBody *RequestBody        `json:"requestBody,omitempty"`
+		Responses   map[string]Response `json:"responses"`
+	}
+
+	//Parameter : A query parameter an operation accepts
+	Parameter struct {
+		Name        string  `json:"name"`
+		In          string  `json:"in"`
+		Description string  `json:"description,omitempty"`
+		Required    bool    `json:"required,omitempty"`
+		Style       string  `json:"style,omitempty"`
+		Explode     *bool   `json:"explode,omitempty"`
+		Schema      *Schema `json:"schema"`
+	}
+
+	//RequestBody : The body an operation accepts
+	RequestBody struct {
+		Description string               `json:"description,omitempty"`
+		Required    bool                 `json:"required,omitempty"`
+		Content     map[string]MediaType `json:"content"`
+	}
+
+	//Response : A response an operation can return
+	Response struct {
+		Description string               `json:"description"`
+		Headers     map[string]Header    `json:"headers,omitempty"`
+		Content     map[string]MediaType `json:"content,omitempty"`
+	}
+
+	//Header : A header sent with a response
+	Header struct {
+		Description string  `json:"description,omitempty"`
+		Schema      *Schema `json:"schema"`
+	}
+
+	//MediaType : The body of a response in one content type
+	MediaType struct {
+		Schema *Schema `json:"schema"`
+	}
+
+	//Components : Schemas shared by several operations, referenced with $ref
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas"`
+	}
+
+	//Schema : A JSON schema, only the keywords this API needs are supported
+	Schema struct {
+		Ref         string             `json:"$ref,omitempty"`
+		Type        string             `json:"type,omitempty"`
+		Format      string             `json:"format,omitempty"`
+		Description string             `json:"description,omitempty"`
+		Default     interface{}        `json:"default,omitempty"`
+		Enum        []string           `json:"enum,omitempty"`
+		Pattern     string             `json:"pattern,omitempty"`
+		Minimum     *int               `json:"minimum,omitempty"`
+		Maximum     *int               `json:"maximum,omitempty"`
+		MinLength   *int               `json:"minLength,omitempty"`
+		Items       *Schema            `json:"items,omitempty"`
+		Properties  map[string]*Schema `json:"properties,omitempty"`
+		Required    []string           `json:"required,omitempty"`
+	}
+)
+
+//NewDocument : Creates an empty document for the API described by info
+func NewDocument(info Info) *Document {
+	return &Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Paths:      make(map[string]*PathItem),
+		Components: Components{Schemas: make(map[string]*Schema)},
+	}
+}
+
+//Int : a pointer to n, for the optional numeric keywords of a schema
+func Int(n int) *int {
+	return &n
+}
+
+//Bool : a pointer to b, for the optional boolean fields of a parameter
+func Bool(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,89 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+)
+
+/*Schema : Describes the JSON encoding of v, registering the schema of every struct it uses as a component
+Structs are returned as a $ref to their component so they're only described once. Properties come from the json tags
+of their fields, fields tagged "-" are left out and fields without omitempty are required.
+*/
+func (doc *Document) Schema(v interface{}) *Schema {
+	return doc.schemaOf(reflect.TypeOf(v))
+}
+
+func (doc *Document) schemaOf(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return doc.schemaOf(t.Elem())
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: doc.schemaOf(t.Elem())}
+	case reflect.Struct:
+		ref := &Schema{Ref: "#/components/schemas/" + t.Name()}
+
+		// anonymous structs have no name to be referenced by
+		if t.Name() == "" {
+			return doc.object(t)
+		}
+
+		if _, ok := doc.Components.Schemas[t.Name()]; !ok {
+			// registered before the fields are described so types which refer to themselves terminate
+			doc.Components.Schemas[t.Name()] = &Schema{}
+			*doc.Components.Schemas[t.Name()] = *doc.object(t)
+		}
+
+		return ref
+	}
+
+	// anything else (maps, interfaces) can hold any value
+	return &Schema{}
+}
+
+//object : describes the exported fields of a struct
+func (doc *Document) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		name := tag[0]
+
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = doc.schemaOf(field.Type)
+
+		omitempty := false
+
+		for _, option := range tag[1:] {
+			omitempty = omitempty || option == "omitempty"
+		}
+
+		if !omitempty {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema
+}
@@ -0,0 +1,288 @@
+package openapi
+
+import (
+	"assessment/apperror"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+/*Validate : Checks the query parameters and the body of a request against the operation
+Every parameter which isn't valid is reported rather than just the first one, an empty result means the request is valid.
+Parameters the operation doesn't describe are ignored. Array parameters can be repeated or comma separated.
+*/
+func (doc *Document) Validate(operation *Operation, r *http.Request) []apperror.InvalidParameter {
+	var invalid []apperror.InvalidParameter
+
+	query := r.URL.Query()
+
+	for _, parameter := range operation.Parameters {
+		if parameter.In != "query" {
+			continue
+		}
+
+		invalid = append(invalid, doc.validateQuery(parameter, query[parameter.Name])...)
+	}
+
+	if operation.RequestBody != nil {
+		invalid = append(invalid, doc.validateBody(operation.RequestBody, r)...)
+	}
+
+	return invalid
+}
+
+//validateQuery : checks every value sent for a query parameter
+func (doc *Document) validateQuery(parameter Parameter, values []string) []apperror.InvalidParameter {
+	schema := doc.resolve(parameter.Schema)
+
+	var sent []string
+
+	// empty values are treated as if the parameter wasn't sent, e.g. ?limit= uses the default limit
+	for _, value := range values {
+		if schema.Type == "array" {
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					sent = append(sent, item)
+				}
+			}
+		} else if value != "" {
+			sent = append(sent, value)
+		}
+	}
+
+	if len(sent) == 0 {
+		if parameter.Required {
+			return []apperror.InvalidParameter{{Name: parameter.Name, In: "query", Reason: "is required"}}
+		}
+
+		return nil
+	}
+
+	if schema.Type != "array" {
+		if len(sent) > 1 {
+			return []apperror.InvalidParameter{{Name: parameter.Name, In: "query", Reason: "can't be sent more than once"}}
+		}
+
+		return doc.check(schema, queryValue(schema, sent[0]), parameter.Name, "query")
+	}
+
+	var invalid []apperror.InvalidParameter
+
+	for _, item := range sent {
+		invalid = append(invalid, doc.check(schema.Items, queryValue(doc.resolve(schema.Items), item), parameter.Name, "query")...)
+	}
+
+	return invalid
+}
+
+//queryValue : converts a query value into the value JSON would decode it to, so it can be checked like one
+func queryValue(schema *Schema, raw string) interface{} {
+	switch schema.Type {
+	case "integer", "number":
+		return json.Number(raw)
+	case "boolean":
+		if value, err := strconv.ParseBool(raw); err == nil {
+			return value
+		}
+	}
+
+	return raw
+}
+
+//validateBody : checks the JSON body of a request, the body is put back so the handler can still read it
+func (doc *Document) validateBody(body *RequestBody, r *http.Request) []apperror.InvalidParameter {
+	var raw []byte
+
+	if r.Body != nil {
+		var err error
+
+		if raw, err = io.ReadAll(r.Body); err != nil {
+			return []apperror.InvalidParameter{{Name: "body", In: "body", Reason: "couldn't be read"}}
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(raw))
+	}
+
+	if len(bytes.TrimSpace(raw)) == 0 {
+		if body.Required {
+			return []apperror.InvalidParameter{{Name: "body", In: "body", Reason: "is required"}}
+		}
+
+		return nil
+	}
+
+	media, ok := body.Content["application/json"]
+
+	if !ok {
+		return nil
+	}
+
+	var value interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&value); err != nil {
+		return []apperror.InvalidParameter{{Name: "body", In: "body", Reason: "isn't valid JSON"}}
+	}
+
+	return doc.check(media.Schema, value, "body", "body")
+}
+
+//resolve : follows a $ref to the component it points at
+func (doc *Document) resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		schema = doc.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+
+	if schema == nil {
+		return &Schema{}
+	}
+
+	return schema
+}
+
+//check : checks a value against the schema, nested values are named after their path e.g. body.data[0].id
+func (doc *Document) check(schema *Schema, value interface{}, name, in string) []apperror.InvalidParameter {
+	schema = doc.resolve(schema)
+
+	invalid := func(format string, args ...interface{}) []apperror.InvalidParameter {
+		return []apperror.InvalidParameter{{Name: name, In: in, Reason: fmt.Sprintf(format, args...)}}
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+
+		if !ok {
+			return invalid("has to be an object")
+		}
+
+		var result []apperror.InvalidParameter
+
+		for _, property := range schema.Required {
+			if _, ok := object[property]; !ok {
+				result = append(result, apperror.InvalidParameter{Name: name + "." + property, In: in, Reason: "is required"})
+			}
+		}
+
+		// checked in a fixed order so the same request always reports the same errors
+		properties := make([]string, 0, len(schema.Properties))
+
+		for property := range schema.Properties {
+			properties = append(properties, property)
+		}
+
+		sort.Strings(properties)
+
+		for _, property := range properties {
+			if v, ok := object[property]; ok {
+				result = append(result, doc.check(schema.Properties[property], v, name+"."+property, in)...)
+			}
+		}
+
+		return result
+	case "array":
+		array, ok := value.([]interface{})
+
+		if !ok {
+			return invalid("has to be an array")
+		}
+
+		var result []apperror.InvalidParameter
+
+		for i, item := range array {
+			result = append(result, doc.check(schema.Items, item, fmt.Sprintf("%s[%d]", name, i), in)...)
+		}
+
+		return result
+	case "string":
+		s, ok := value.(string)
+
+		if !ok {
+			return invalid("has to be a string")
+		}
+
+		if schema.MinLength != nil && utf8.RuneCountInString(s) < *schema.MinLength {
+			if *schema.MinLength == 1 {
+				return invalid("can't be empty")
+			}
+
+			return invalid("has to be at least %d characters long", *schema.MinLength)
+		}
+
+		if len(schema.Enum) > 0 && !contains(schema.Enum, s) {
+			return invalid("has to be one of %s, got %q", strings.Join(schema.Enum, ", "), s)
+		}
+
+		if schema.Pattern != "" {
+			if matched, err := regexp.MatchString(schema.Pattern, s); err == nil && !matched {
+				return invalid("has to match %s, got %q", schema.Pattern, s)
+			}
+		}
+	case "integer", "number":
+		n, ok := value.(json.Number)
+
+		if !ok {
+			return invalid("has to be a number")
+		}
+
+		var (
+			number float64
+			err    error
+		)
+
+		if schema.Type == "integer" {
+			var i int64
+
+			i, err = strconv.ParseInt(string(n), 10, 64)
+			number = float64(i)
+		} else {
+			number, err = strconv.ParseFloat(string(n), 64)
+		}
+
+		if errors.Is(err, strconv.ErrRange) {
+			return invalid("is too large")
+		}
+
+		if err != nil {
+			if schema.Type == "integer" {
+				return invalid("has to be a whole number")
+			}
+
+			return invalid("has to be a number")
+		}
+
+		if schema.Minimum != nil && number < float64(*schema.Minimum) {
+			return invalid("has to be %d or greater", *schema.Minimum)
+		}
+
+		if schema.Maximum != nil && number > float64(*schema.Maximum) {
+			return invalid("can't be greater than %d", *schema.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return invalid("has to be true or false")
+		}
+	}
+
+	return nil
+}
+
+func contains(list []string, item string) bool {
+	for _, entry := range list {
+		if entry == item {
+			return true
+		}
+	}
+
+	return false
+}
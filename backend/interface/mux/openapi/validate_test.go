@@ -0,0 +1,95 @@
+package openapi_test
+
+import (
+	"assessment/apperror"
+	"assessment/interface/mux/openapi"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type address struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type customer struct {
+	Name      string    `json:"name"`
+	Age       int       `json:"age"`
+	Addresses []address `json:"addresses,omitempty"`
+	Internal  string    `json:"-"`
+}
+
+func TestDocument_Schema(t *testing.T) {
+	doc := openapi.NewDocument(openapi.Info{Title: "test", Version: "v1"})
+
+	schema := doc.Schema(customer{})
+
+	require.Equal(t, "#/components/schemas/customer", schema.Ref)
+	require.Equal(t, []string{"name", "age"}, doc.Components.Schemas["customer"].Required)
+	require.NotContains(t, doc.Components.Schemas["customer"].Properties, "Internal")
+	require.Equal(t, "#/components/schemas/address", doc.Components.Schemas["customer"].Properties["addresses"].Items.Ref)
+	require.Equal(t, []string{"city"}, doc.Components.Schemas["address"].Required)
+}
+
+func TestDocument_Validate(t *testing.T) {
+	doc := openapi.NewDocument(openapi.Info{Title: "test", Version: "v1"})
+
+	body := doc.Schema(customer{})
+	doc.Components.Schemas["customer"].Properties["age"].Minimum = openapi.Int(18)
+
+	operation := &openapi.Operation{
+		Parameters: []openapi.Parameter{
+			{Name: "tag", In: "query", Schema: &openapi.Schema{Type: "array", Items: &openapi.Schema{Type: "string", Enum: []string{"a", "b"}}}},
+			{Name: "verbose", In: "query", Schema: &openapi.Schema{Type: "boolean"}},
+			{Name: "size", In: "query", Required: true, Schema: &openapi.Schema{Type: "integer", Maximum: openapi.Int(10)}},
+		},
+		RequestBody: &openapi.RequestBody{Required: true, Content: map[string]openapi.MediaType{
+			"application/json": {Schema: body},
+		}},
+	}
+
+	var testCases = []struct {
+		target   string
+		body     string
+		expected []apperror.InvalidParameter
+	}{
+		{"/?size=5&tag=a,b&tag=a&verbose=true", `{"name": "x", "age": 20}`, nil},
+		{"/?size=99999999999999999999", `{"name": "x", "age": 20}`, []apperror.InvalidParameter{
+			{Name: "size", In: "query", Reason: "is too large"},
+		}},
+		{"/?tag=c&verbose=maybe", ``, []apperror.InvalidParameter{
+			{Name: "tag", In: "query", Reason: `has to be one of a, b, got "c"`},
+			{Name: "verbose", In: "query", Reason: "has to be true or false"},
+			{Name: "size", In: "query", Reason: "is required"},
+			{Name: "body", In: "body", Reason: "is required"},
+		}},
+		{"/?size=11", `{"name": 1, "age": 12.5, "addresses": [{"zip": "1"}]}`, []apperror.InvalidParameter{
+			{Name: "size", In: "query", Reason: "can't be greater than 10"},
+			{Name: "body.addresses[0].city", In: "body", Reason: "is required"},
+			{Name: "body.age", In: "body", Reason: "has to be a whole number"},
+			{Name: "body.name", In: "body", Reason: "has to be a string"},
+		}},
+		{"/?size=1", `{"age": 17}`, []apperror.InvalidParameter{
+			{Name: "body.name", In: "body", Reason: "is required"},
+			{Name: "body.age", In: "body", Reason: "has to be 18 or greater"},
+		}},
+		{"/?size=1", `{"name": `, []apperror.InvalidParameter{
+			{Name: "body", In: "body", Reason: "isn't valid JSON"},
+		}},
+	}
+
+	for _, tCase := range testCases {
+		req := httptest.NewRequest(http.MethodPost, tCase.target, strings.NewReader(tCase.body))
+
+		require.Equal(t, tCase.expected, doc.Validate(operation, req), tCase.target)
+
+		// the body can still be read by the handler
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+		require.Equal(t, tCase.body, string(body))
+	}
+}
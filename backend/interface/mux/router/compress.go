@@ -0,0 +1,308 @@
+package router
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// compressMinSize : bodies smaller than this are sent as they are, compressing them costs more than it saves
+const compressMinSize = 1024
+
+//encoder : a compressor which can be reset to write to another response, so it can be pooled
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+//zstdEncoder : adapts the zstd encoder, whose Reset returns an error, to the encoder interface
+type zstdEncoder struct {
+	*zstd.Encoder
+}
+
+func (e zstdEncoder) Reset(w io.Writer) {
+	e.Encoder.Reset(w)
+}
+
+// encoders : the encodings responses can be compressed with, in the order they're preferred when a client accepts several
+var encoders = []struct {
+	name string
+	pool *sync.Pool
+}{
+	{"zstd", &sync.Pool{New: func() interface{} {
+		e, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return zstdEncoder{e}
+	}}},
+	{"gzip", &sync.Pool{New: func() interface{} {
+		return gzip.NewWriter(nil)
+	}}},
+	{"deflate", &sync.Pool{New: func() interface{} {
+		e, _ := flate.NewWriter(nil, flate.DefaultCompression)
+		return e
+	}}},
+}
+
+// incompressible : content types which are already compressed, compressing them again only wastes time
+var incompressible = []string{"image/", "video/", "audio/", "font/woff", "application/zip", "application/gzip", "application/zstd", "application/x-gzip"}
+
+/*compress : Compresses responses with the best encoding the client accepts
+The first compressMinSize bytes of a body are held back to decide whether it is worth compressing, after that the body is
+streamed through the encoder. Encoded responses get a weak ETag since their bytes differ from the unencoded response.
+A 304 only gets the weak ETag when it declares the Content-Length of a body which would have been encoded.
+*/
+func compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the body differs with the Accept-Encoding header, so shared caches have to key on it
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiate(r.Header.Get("Accept-Encoding"))
+
+		if encoding < 0 || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.close()
+
+		next.ServeHTTP(cw, r)
+	})
+}
+
+/*negotiate : picks the encoding to use from an Accept-Encoding header, -1 when nothing should be compressed
+Encodings are weighted by their q value, e.g. "gzip;q=0.5, deflate" prefers deflate, and q=0 rules an encoding out.
+*/
+func negotiate(header string) int {
+	var (
+		best     = -1
+		bestQ    = 0.0
+		wildcard = -1.0
+		q        = make(map[string]float64)
+	)
+
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+
+		if name == "" {
+			continue
+		}
+
+		weight := 1.0
+
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					weight = parsed
+				}
+			}
+		}
+
+		if name == "*" {
+			wildcard = weight
+			continue
+		}
+
+		q[name] = weight
+	}
+
+	for i, e := range encoders {
+		weight, ok := q[e.name]
+
+		// * stands for every encoding the client didn't name
+		if !ok {
+			weight = wildcard
+		}
+
+		if weight > bestQ {
+			best, bestQ = i, weight
+		}
+	}
+
+	return best
+}
+
+//compressWriter : a response writer which compresses the body once it is known to be worth it
+type compressWriter struct {
+	http.ResponseWriter
+	encoding int
+
+	status  int
+	buffer  []byte  // the start of the body, held until it is decided whether to compress it
+	decided bool    // whether the headers have been written
+	encoder encoder // nil when the body is sent as it is
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.decided || cw.status != 0 {
+		return
+	}
+
+	cw.status = status
+
+	// responses without a body are sent straight away
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		// a 304 stands in for the response which would have been sent, so its ETag has to match that one
+		if status == http.StatusNotModified && cw.wouldEncode() {
+			weakenETag(cw.Header())
+		}
+
+		cw.decided = true
+		cw.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	if cw.decided {
+		if cw.encoder != nil {
+			return cw.encoder.Write(p)
+		}
+
+		return cw.ResponseWriter.Write(p)
+	}
+
+	cw.buffer = append(cw.buffer, p...)
+
+	if len(cw.buffer) < compressMinSize && !cw.skip() {
+		return len(p), nil
+	}
+
+	if err := cw.decide(); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+//Flush : sends whatever has been written so far, compressing it even when it is still short
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.WriteHeader(http.StatusOK)
+		}
+
+		_ = cw.decide()
+	}
+
+	if cw.encoder != nil {
+		_ = cw.encoder.Flush()
+	}
+
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+/*wouldEncode : checks whether the body of a 304 would have been encoded had it been sent
+Only the declared length tells its size, without it the body is taken to have been sent as it is.
+*/
+func (cw *compressWriter) wouldEncode() bool {
+	length, err := strconv.Atoi(cw.Header().Get("Content-Length"))
+
+	return err == nil && length >= compressMinSize && !cw.skip()
+}
+
+//skip : checks whether the response shouldn't be compressed no matter its size
+func (cw *compressWriter) skip() bool {
+	header := cw.Header()
+
+	if header.Get("Content-Encoding") != "" {
+		return true
+	}
+
+	// a declared length tells the size of the body before all of it has been written
+	if length, err := strconv.Atoi(header.Get("Content-Length")); err == nil && length < compressMinSize {
+		return true
+	}
+
+	contentType := strings.ToLower(header.Get("Content-Type"))
+
+	for _, prefix := range incompressible {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//decide : writes the headers, compressing the body when it is large enough, and sends the buffered part of the body
+func (cw *compressWriter) decide() error {
+	cw.decided = true
+
+	header := cw.Header()
+
+	// once the body is encoded it can no longer be sniffed, so the type is worked out from the unencoded start
+	if header.Get("Content-Type") == "" && len(cw.buffer) > 0 {
+		header.Set("Content-Type", http.DetectContentType(cw.buffer))
+	}
+
+	if !cw.skip() && len(cw.buffer) > 0 {
+		header.Set("Content-Encoding", encoders[cw.encoding].name)
+		header.Del("Content-Length")
+		weakenETag(header)
+
+		cw.encoder = encoders[cw.encoding].pool.Get().(encoder)
+		cw.encoder.Reset(cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	buffer := cw.buffer
+	cw.buffer = nil
+
+	if len(buffer) == 0 {
+		return nil
+	}
+
+	if cw.encoder != nil {
+		_, err := cw.encoder.Write(buffer)
+		return err
+	}
+
+	_, err := cw.ResponseWriter.Write(buffer)
+
+	return err
+}
+
+//close : sends the rest of the response and returns the encoder to its pool
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		if cw.status == 0 {
+			return
+		}
+
+		// the whole body turned out to be smaller than compressMinSize
+		cw.decided = true
+		cw.ResponseWriter.WriteHeader(cw.status)
+		_, _ = cw.ResponseWriter.Write(cw.buffer)
+
+		return
+	}
+
+	if cw.encoder != nil {
+		_ = cw.encoder.Close()
+		cw.encoder.Reset(nil)
+		encoders[cw.encoding].pool.Put(cw.encoder)
+		cw.encoder = nil
+	}
+}
+
+//weakenETag : marks the ETag of a response as weak, encoded bodies are equivalent to the unencoded one but not identical
+func weakenETag(header http.Header) {
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set("ETag", "W/"+etag)
+	}
+}
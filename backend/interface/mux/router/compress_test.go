@@ -0,0 +1,144 @@
+package router
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	var testCases = []struct {
+		header   string
+		expected string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"gzip, deflate, br, zstd", "zstd"},
+		{"gzip;q=0.5, deflate", "deflate"},
+		{"zstd;q=0, gzip;q=0.1", "gzip"},
+		{"*", "zstd"},
+		{"*;q=0.2, gzip;q=0.5", "gzip"},
+		{"zstd;q=0, *", "gzip"},
+		{"GZIP", "gzip"},
+		{"*;q=0", ""},
+	}
+
+	for _, tCase := range testCases {
+		name := ""
+
+		if encoding := negotiate(tCase.header); encoding >= 0 {
+			name = encoders[encoding].name
+		}
+
+		require.Equal(t, tCase.expected, name, tCase.header)
+	}
+}
+
+func TestCompress(t *testing.T) {
+	large := `{"data":"` + strings.Repeat("697151594 ", 500) + `"}`
+	small := `{"data":"697151594"}`
+
+	handler := func(body, contentType string) http.Handler {
+		return compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("ETag", `"tag"`)
+
+			// a 304 declares the length of the body it stands in for
+			if r.Header.Get("If-None-Match") != "" {
+				w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			// written in pieces to check that the body is streamed through the encoder
+			for i := 0; i < len(body); i += 100 {
+				end := i + 100
+
+				if end > len(body) {
+					end = len(body)
+				}
+
+				_, _ = w.Write([]byte(body[i:end]))
+			}
+		}))
+	}
+
+	serve := func(h http.Handler, acceptEncoding string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+
+		for i := 0; i < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"gzip":    func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"deflate": func(r io.Reader) (io.Reader, error) { return flate.NewReader(r), nil },
+		"zstd": func(r io.Reader) (io.Reader, error) {
+			d, err := zstd.NewReader(r)
+			return d, err
+		},
+	}
+
+	for encoding, decode := range decoders {
+		// run twice so an encoder coming back from the pool is used as well
+		for i := 0; i < 2; i++ {
+			rr := serve(handler(large, "application/json"), encoding)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, encoding, rr.Header().Get("Content-Encoding"))
+			require.Equal(t, `W/"tag"`, rr.Header().Get("ETag"))
+			require.Equal(t, "Accept-Encoding", rr.Header().Get("Vary"))
+			require.Less(t, rr.Body.Len(), len(large))
+
+			reader, err := decode(bytes.NewReader(rr.Body.Bytes()))
+			require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+			body, err := io.ReadAll(reader)
+			require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+			require.Equal(t, large, string(body))
+		}
+	}
+
+	// small bodies, content which is already compressed and clients which don't accept any encoding get the body as it is
+	for _, rr := range []*httptest.ResponseRecorder{
+		serve(handler(small, "application/json"), "gzip"),
+		serve(handler(large, "image/png"), "gzip"),
+		serve(handler(large, "application/json"), ""),
+		serve(handler(large, "application/json"), "br"),
+	} {
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Empty(t, rr.Header().Get("Content-Encoding"))
+		require.Equal(t, `"tag"`, rr.Header().Get("ETag"))
+		require.Equal(t, "Accept-Encoding", rr.Header().Get("Vary"))
+		require.Contains(t, []string{small, large}, rr.Body.String())
+	}
+
+	// a 304 has to carry the tag of the encoded response it stands in for
+	rr := serve(handler(large, "application/json"), "gzip", "If-None-Match", `W/"tag"`)
+
+	require.Equal(t, http.StatusNotModified, rr.Code)
+	require.Equal(t, `W/"tag"`, rr.Header().Get("ETag"))
+	require.Empty(t, rr.Body.String())
+
+	// the body of a small response would have been sent as it is, along with its strong tag
+	rr = serve(handler(small, "application/json"), "gzip", "If-None-Match", `"tag"`)
+
+	require.Equal(t, http.StatusNotModified, rr.Code)
+	require.Equal(t, `"tag"`, rr.Header().Get("ETag"))
+}
@@ -0,0 +1,324 @@
+package router
+
+import (
+	"assessment/config"
+	"assessment/interface/mux/helper"
+	"assessment/interface/mux/openapi"
+	"assessment/model"
+	"assessment/service"
+	_ "embed"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+//describe : documents a route for the version of the API it is served under, the version is empty for unversioned routes
+type describe func(doc *openapi.Document, v version) openapi.Operation
+
+// operations : the documentation of every route, by the name it is registered with minus the version
+var operations = map[string]describe{
+	"listPhoneNumbers": func(doc *openapi.Document, v version) openapi.Operation {
+		return openapi.Operation{
+			Summary:     "List phone numbers",
+			Description: "Lists the customers' phone numbers along with their country and whether they're valid for it.",
+			Parameters:  listParameters(config.FetchConfig().PhoneNumbersPageSize, false),
+			Responses:   listResponses(doc, v),
+		}
+	},
+	"searchPhoneNumbers": func(doc *openapi.Document, v version) openapi.Operation {
+		return openapi.Operation{
+			Summary: "Search phone numbers",
+			Description: "Searches the national numbers (when q is made up of digits) or the customers' names, " +
+				"the parts of each result which matched are returned in highlights.",
+			Parameters: listParameters(config.FetchConfig().SearchPageSize, true),
+			Responses:  listResponses(doc, v),
+		}
+	},
+	"openapi": func(doc *openapi.Document, v version) openapi.Operation {
+		return openapi.Operation{
+			Summary: "This document",
+			Responses: map[string]openapi.Response{
+				"200": {Description: "The OpenAPI document of the API", Content: map[string]openapi.MediaType{
+					"application/json": {Schema: &openapi.Schema{Type: "object"}},
+				}},
+			},
+		}
+	},
+	"debugVars": func(doc *openapi.Document, v version) openapi.Operation {
+		return openapi.Operation{
+			Summary: "Runtime statistics",
+			Description: "The variables published through expvar: memory statistics, the command line and the hits, " +
+				"misses and evictions of the validation cache (validationCache).",
+			Responses: map[string]openapi.Response{
+				"200": {Description: "Every published variable, by name", Content: map[string]openapi.MediaType{
+					"application/json": {Schema: &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{
+						"validationCache": doc.Schema(service.ValidationCacheStats{}),
+					}}},
+				}},
+			},
+		}
+	},
+	"health": func(doc *openapi.Document, v version) openapi.Operation {
+		health := map[string]openapi.MediaType{"application/json": {Schema: doc.Schema(model.Health{})}}
+
+		return openapi.Operation{
+			Summary:     "Health check",
+			Description: "Whether the phone numbers can be read, along with the state of the database connection pools.",
+			Responses: map[string]openapi.Response{
+				"200": {Description: "The database can be reached", Content: health},
+				"503": {Description: "The database can't be reached", Content: health},
+			},
+		}
+	},
+	"docs": func(doc *openapi.Document, v version) openapi.Operation {
+		return openapi.Operation{
+			Summary: "Browsable documentation of the API",
+			Responses: map[string]openapi.Response{
+				"200": {Description: "A page rendering this document", Content: map[string]openapi.MediaType{
+					"text/html": {Schema: &openapi.Schema{Type: "string"}},
+				}},
+			},
+		}
+	},
+}
+
+/*Spec : Generates the OpenAPI document of every route registered on the router
+Routes are documented by the name they're registered with, a name is the operation prefixed by the version of the API
+the route belongs to, e.g. v1.listPhoneNumbers. Routes without a name or without an entry in operations are left out.
+*/
+func Spec(router *mux.Router) (*openapi.Document, error) {
+	doc := openapi.NewDocument(openapi.Info{
+		Title:       "Phone Numbers",
+		Description: "Lists and searches customers' phone numbers, categorized by country and validity.",
+		Version:     versions[len(versions)-1].name,
+	})
+
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		// path prefixes and subrouters have no name
+		if route.GetName() == "" {
+			return nil
+		}
+
+		path, err := route.GetPathTemplate()
+
+		if err != nil {
+			return err
+		}
+
+		if operation, ok := document(doc, route.GetName()); ok {
+			doc.Paths[path] = &openapi.PathItem{Get: &operation}
+		}
+
+		return nil
+	})
+
+	return doc, err
+}
+
+//document : documents the route registered with the name provided
+func document(doc *openapi.Document, name string) (openapi.Operation, bool) {
+	var (
+		v  version
+		id = name
+	)
+
+	if i := strings.Index(name, "."); i >= 0 {
+		v, id = version{name: name[:i]}, name[i+1:]
+	}
+
+	describe, ok := operations[id]
+
+	if !ok {
+		return openapi.Operation{}, false
+	}
+
+	// deprecated routes behave exactly like the version replacing them
+	deprecated := v.name == legacy
+
+	if deprecated {
+		v.name = legacySuccessor
+	}
+
+	for _, known := range versions {
+		if known.name == v.name {
+			v = known
+		}
+	}
+
+	operation := describe(doc, v)
+	operation.OperationID = id
+
+	if v.name != "" {
+		operation.Tags = []string{v.name}
+		operation.OperationID = id + strings.ToUpper(v.name[:1]) + v.name[1:]
+	}
+
+	if deprecated {
+		operation.Tags = []string{legacy}
+		operation.OperationID = id + "Legacy"
+		operation.Deprecated = true
+
+		for status, response := range operation.Responses {
+			response.Headers = deprecationHeaders(response.Headers)
+			operation.Responses[status] = response
+		}
+	}
+
+	return operation, true
+}
+
+//listParameters : the query parameters of the routes listing phone numbers, with the limits the route was configured with
+func listParameters(pageSize config.PageSize, searchRequired bool) []openapi.Parameter {
+	pageSize = pageSize.OrDefault()
+
+	limit := &openapi.Schema{Type: "integer", Minimum: openapi.Int(0), Default: pageSize.Default}
+
+	// limits above the maximum are lowered to it rather than rejected when clamping
+	if !pageSize.Clamp {
+		limit.Maximum = openapi.Int(pageSize.Max)
+	}
+
+	var sortKeys []string
+
+	for _, field := range service.SortFields() {
+		sortKeys = append(sortKeys, field, "-"+field)
+	}
+
+	return []openapi.Parameter{
+		listParameter("country", "Only return numbers from these countries", &openapi.Schema{Type: "string", MinLength: openapi.Int(1)}),
+		listParameter("country!", "Leave out numbers from these countries", &openapi.Schema{Type: "string", MinLength: openapi.Int(1)}),
+		listParameter("state", "Only return numbers in these states, OK for valid numbers and NOK for invalid ones",
+			&openapi.Schema{Type: "string", Enum: service.States}),
+		{Name: "page", In: "query", Description: "The page to return, can't be used with offset",
+			Schema: &openapi.Schema{Type: "integer", Minimum: openapi.Int(1), Default: 1}},
+		{Name: "offset", In: "query", Description: "The number of results to skip, can't be used with page",
+			Schema: &openapi.Schema{Type: "integer", Minimum: openapi.Int(0)}},
+		{Name: "limit", In: "query", Description: "The number of results per page, 0 means the default", Schema: limit},
+		listParameter("sort", "Order results by these fields in turn, prefix a field with - for descending order",
+			&openapi.Schema{Type: "string", Enum: sortKeys}),
+		listParameter("fields", "Only return these fields of each result", &openapi.Schema{Type: "string", Enum: service.Fields()}),
+		{Name: "q", In: "query", Required: searchRequired,
+			Description: "Digits search the national numbers (a trailing * only matches their start), anything else searches the names",
+			Schema:      &openapi.Schema{Type: "string", MinLength: openapi.Int(1), Pattern: service.SearchPattern}},
+	}
+}
+
+//listParameter : a parameter which can be repeated or comma separated
+func listParameter(name, description string, items *openapi.Schema) openapi.Parameter {
+	return openapi.Parameter{
+		Name:        name,
+		In:          "query",
+		Description: description + ", repeated or comma separated",
+		Style:       "form",
+		Explode:     openapi.Bool(true),
+		Schema:      &openapi.Schema{Type: "array", Items: items},
+	}
+}
+
+//listResponses : the responses of the routes listing phone numbers, in the envelope of the version they're served under
+func listResponses(doc *openapi.Document, v version) map[string]openapi.Response {
+	failure := func(description string) openapi.Response {
+		return openapi.Response{Description: description, Content: map[string]openapi.MediaType{
+			"application/json": {Schema: doc.Schema(helper.Failure{})},
+		}}
+	}
+
+	return map[string]openapi.Response{
+		"200": {
+			Description: "A page of phone numbers",
+			Headers: map[string]openapi.Header{
+				"Link":          {Description: "The pagination links, as in RFC 8288", Schema: &openapi.Schema{Type: "string"}},
+				"ETag":          {Description: "A strong tag of the body, send it in If-None-Match to revalidate", Schema: &openapi.Schema{Type: "string"}},
+				"Cache-Control": {Description: "How the response may be cached, configured per route", Schema: &openapi.Schema{Type: "string"}},
+				"Last-Modified": {Description: "When the phone numbers last changed, when configured for the route", Schema: &openapi.Schema{Type: "string"}},
+			},
+			Content: map[string]openapi.MediaType{
+				"application/json": {Schema: v.schema(doc)},
+			},
+		},
+		"304": {Description: "The copy the client holds, named by If-None-Match or If-Modified-Since, is still current"},
+		"400": failure("A parameter is invalid"),
+		"404": failure("A country filtered by is unknown"),
+		"500": failure("The phone numbers couldn't be read"),
+		"504": failure("Reading the phone numbers took longer than the configured query timeout"),
+	}
+}
+
+//deprecationHeaders : adds the headers sent by deprecated routes to the headers of a response
+func deprecationHeaders(headers map[string]openapi.Header) map[string]openapi.Header {
+	result := map[string]openapi.Header{
+		"Deprecation": {Description: "When the route was deprecated, as in RFC 9745", Schema: &openapi.Schema{Type: "string"}},
+		"Sunset":      {Description: "When the route will be removed, as in RFC 8594", Schema: &openapi.Schema{Type: "string"}},
+		"Link": {Description: `The same request on the version replacing this route, with rel="successor-version", ` +
+			"followed by the pagination links", Schema: &openapi.Schema{Type: "string"}},
+	}
+
+	for name, header := range headers {
+		if _, ok := result[name]; !ok {
+			result[name] = header
+		}
+	}
+
+	return result
+}
+
+//v1Schema : the schema of successful responses in the v1 envelope
+func v1Schema(doc *openapi.Document) *openapi.Schema {
+	return &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"message": {Type: "string"},
+			"result":  doc.Schema(model.Result{}),
+		},
+		Required: []string{"message", "result"},
+	}
+}
+
+//spec : the OpenAPI document of a router, generated once every route is registered
+type spec struct {
+	router *mux.Router
+	once   sync.Once
+	doc    *openapi.Document
+	body   []byte
+	err    error
+}
+
+//load : generates the document on the first call, the routes can't change once requests are being served
+func (s *spec) load() (*openapi.Document, []byte, error) {
+	s.once.Do(func() {
+		if s.doc, s.err = Spec(s.router); s.err == nil {
+			s.body, s.err = json.Marshal(s.doc)
+		}
+
+		if s.err != nil {
+			log.Printf("Error generating the OpenAPI document: %v", s.err)
+		}
+	})
+
+	return s.doc, s.body, s.err
+}
+
+//serve : serves the OpenAPI document
+func (s *spec) serve(w http.ResponseWriter, r *http.Request) {
+	_, body, err := s.load()
+
+	if err != nil {
+		helper.ReturnFailure(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+//serveDocs : serves the page rendering the OpenAPI document
+func serveDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(docsPage)
+}
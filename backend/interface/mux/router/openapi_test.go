@@ -0,0 +1,94 @@
+package router_test
+
+import (
+	"assessment/interface/mux/controller"
+	"assessment/interface/mux/openapi"
+	"assessment/interface/mux/router"
+	repoMock "assessment/repository/mock"
+	"assessment/service"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newRouter() *mux.Router {
+	svc := service.NewNumberService(service.NewValidator(), new(repoMock.PhoneNumberRepository))
+
+	return router.InitRouter(controller.NewNumberController(svc))
+}
+
+// every route serving requests has to be documented, a route added without an entry in the spec fails here
+func TestSpec_DocumentsEveryRoute(t *testing.T) {
+	rt := newRouter()
+
+	doc, err := router.Spec(rt)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	var routes int
+
+	err = rt.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		// path prefixes and subrouters don't serve requests themselves
+		if route.GetHandler() == nil {
+			return nil
+		}
+
+		routes++
+
+		path, err := route.GetPathTemplate()
+		require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+		require.Contains(t, doc.Paths, path, "route %s (%q) has no entry in the OpenAPI document", path, route.GetName())
+		require.NotNil(t, doc.Paths[path].Get, "route %s has no GET operation in the OpenAPI document", path)
+		require.NotEmpty(t, doc.Paths[path].Get.Responses, "route %s has no responses in the OpenAPI document", path)
+
+		return nil
+	})
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	require.Len(t, doc.Paths, routes)
+
+	operationIDs := make(map[string]bool)
+
+	for path, item := range doc.Paths {
+		require.False(t, operationIDs[item.Get.OperationID], "operation ID %q of %s is used twice", item.Get.OperationID, path)
+		operationIDs[item.Get.OperationID] = true
+	}
+
+	require.True(t, doc.Paths["/phone-numbers"].Get.Deprecated)
+	require.False(t, doc.Paths["/v1/phone-numbers"].Get.Deprecated)
+}
+
+func TestSpec_Served(t *testing.T) {
+	rt := newRouter()
+
+	rr := httptest.NewRecorder()
+	rt.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var doc openapi.Document
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+	require.Equal(t, openapi.Version, doc.OpenAPI)
+
+	search := doc.Paths["/v1/search"].Get
+
+	for _, parameter := range search.Parameters {
+		require.Equal(t, parameter.Name == "q", parameter.Required, parameter.Name)
+	}
+
+	// every schema referenced has to be defined
+	require.Contains(t, doc.Components.Schemas, "Result")
+	require.Contains(t, doc.Components.Schemas, "Data")
+	require.Contains(t, doc.Components.Schemas, "Failure")
+
+	rr = httptest.NewRecorder()
+	rt.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Header().Get("Content-Type"), "text/html")
+	require.Contains(t, rr.Body.String(), "/openapi.json")
+}
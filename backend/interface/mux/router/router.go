@@ -1,29 +1,125 @@
 package router
 
 import (
+	"assessment/apperror"
 	"assessment/interface/mux/controller"
+	"assessment/interface/mux/helper"
+	"assessment/interface/mux/openapi"
+	"expvar"
 	"github.com/gorilla/mux"
 	"net/http"
 )
 
+//version : a version of the API, served under its name as a path prefix
+type version struct {
+	name     string
+	envelope helper.Envelope
+	schema   func(doc *openapi.Document) *openapi.Schema // describes successful responses in the envelope
+}
+
+// versions : every version of the API being served, they share the routes and differ in how responses are wrapped
+var versions = []version{
+	{name: "v1", envelope: helper.V1, schema: v1Schema},
+}
+
+// the routes were first served without a version prefix, they're kept as a deprecated alias of v1 until the sunset
+const (
+	legacy            = "legacy"
+	legacySuccessor   = "v1"
+	legacyDeprecation = "@1792368000" // 19 Oct 2026, as an RFC 9745 date
+	legacySunset      = "Mon, 19 Apr 2027 00:00:00 GMT"
+)
+
 //InitRouter : Initialize the mux router to be used for multiplexing requests
 func InitRouter(controller *controller.Controller) *mux.Router {
 	router := mux.NewRouter()
+	router.Use(compress)
+
+	doc := &spec{router: router}
 
-	pathRouter := router.PathPrefix("/phone-numbers").Subrouter()
+	for _, v := range versions {
+		routes(router.PathPrefix("/"+v.name).Subrouter(), v.name, controller.WithEnvelope(v.envelope), doc)
+	}
+
+	router.HandleFunc("/openapi.json", doc.serve).Name("openapi")
+
+	router.HandleFunc("/docs", serveDocs).Name("docs")
+
+	router.Handle("/debug/vars", expvar.Handler()).Name("debugVars")
 
-	pathRouter.HandleFunc("", controller.FetchAllPhoneNumbers)
+	router.HandleFunc("/health", controller.Health).Name("health")
+
+	// registered last so every other route is matched first
+	legacyRouter := router.NewRoute().Subrouter()
+	legacyRouter.Use(deprecated("/" + legacySuccessor))
+	routes(legacyRouter, legacy, controller.WithEnvelope(helper.V1), doc)
 
 	return router
 }
 
+/*routes : Registers the routes every version of the API serves, named after the version so they can be documented
+Requests are checked against the documentation of their route before they reach the controller.
+*/
+func routes(router *mux.Router, version string, controller *controller.Controller, doc *spec) {
+	router.Use(validate(doc))
+
+	router.HandleFunc("/phone-numbers", controller.FetchAllPhoneNumbers).Name(version + ".listPhoneNumbers")
+
+	router.HandleFunc("/search", controller.Search).Name(version + ".searchPhoneNumbers")
+}
+
+/*deprecated : Marks the responses of a route as deprecated
+The Deprecation (RFC 9745) and Sunset (RFC 8594) headers tell clients when the route was deprecated and when it goes
+away, and the Link header points at the same request on the version which replaces it.
+*/
+func deprecated(successor string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", legacyDeprecation)
+			w.Header().Set("Sunset", legacySunset)
+			w.Header().Add("Link", "<"+successor+r.URL.RequestURI()+`>; rel="successor-version"`)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+/*validate : Rejects requests whose parameters don't match the OpenAPI document of their route
+Every invalid parameter is reported in the response, so the controllers and the service only see well formed requests.
+*/
+func validate(doc *spec) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			document, _, err := doc.load()
+
+			if err != nil {
+				helper.ReturnFailure(w, err)
+				return
+			}
+
+			// the route matched is always registered, it has a template
+			path, _ := mux.CurrentRoute(r).GetPathTemplate()
+
+			if item, ok := document.Paths[path]; ok && item.Get != nil {
+				if invalid := document.Validate(item.Get, r); len(invalid) > 0 {
+					helper.ReturnFailure(w, apperror.ValidationError{Parameters: invalid})
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 //CorsHandler : Handle Preflight CORS request
 func CorsHandler(next http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "OPTIONS, GET")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length")
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, If-None-Match, If-Modified-Since")
+		w.Header().Set("Access-Control-Expose-Headers", "Link, Deprecation, Sunset, ETag, Last-Modified")
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusOK)
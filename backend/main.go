@@ -2,10 +2,14 @@ package main
 
 import (
 	"assessment/config"
-	"assessment/infra/db/sqlite"
+	"assessment/infra/db/memory"
+	"assessment/infra/db/postgres"
 	"assessment/interface/mux/controller"
 	"assessment/interface/mux/router"
+	"assessment/repository"
 	"assessment/service"
+	"expvar"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -18,13 +22,31 @@ func main() {
 		log.Fatalf("An error occurred while trying to load config file: %v\n", err)
 	}
 
-	repo, err := sqlite.NewSqliteClient()
+	// one-off commands run instead of the server
+	if len(os.Args) > 1 {
+		if err = runCommand(os.Args[1]); err != nil {
+			log.Fatalf("%s failed: %v\n", os.Args[1], err)
+		}
+
+		return
+	}
+
+	repo, err := newRepository(config.FetchConfig().DatabaseDriver)
 
 	if err != nil {
 		log.Fatalf("An error occurred while bringing up the repository: %v\n", err)
 	}
 
-	svc := service.NewNumberService(service.NewValidator(), repo)
+	validator := service.NewCachedValidator(service.NewValidator(), config.FetchConfig().ValidationCacheSize)
+
+	// the hits and misses of the cache are served at /debug/vars
+	expvar.Publish("validationCache", expvar.Func(func() interface{} { return validator.Stats() }))
+
+	svc := service.NewNumberService(validator, repo,
+		service.WithResultCache(config.FetchConfig().ResultCacheTTL),
+		service.WithQueryTimeout(config.FetchConfig().QueryTimeout),
+		service.WithValidationWorkers(config.FetchConfig().ValidationWorkers),
+	)
 
 	numController := controller.NewNumberController(svc)
 
@@ -41,3 +63,30 @@ func main() {
 		log.Fatalln(err)
 	}
 }
+
+/*runCommand : Runs a command given on the command line
+	- create-search-index : creates the FTS5 index names are searched with in the SQLite database, if it's missing
+	- migrate : sets up the schema of the PostgreSQL database, if it's missing
+*/
+func runCommand(name string) error {
+	switch name {
+	case "create-search-index":
+		return createSearchIndex()
+	case "migrate":
+		return postgres.Migrate()
+	}
+
+	return fmt.Errorf("unknown command %q, the commands are create-search-index and migrate", name)
+}
+
+//newRepository : Connects to the database chosen with DB_DRIVER
+func newRepository(driver string) (repository.PhoneNumberRepository, error) {
+	switch driver {
+	case config.PostgresDriver:
+		return postgres.NewPostgresClient()
+	case config.MemoryDriver:
+		return memory.NewMemoryClient()
+	}
+
+	return newSqliteRepository()
+}
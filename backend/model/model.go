@@ -1,24 +1,121 @@
 package model
 
+import "time"
+
 type (
 	//Result : Used for storing results from operations
 	Result struct {
-		Data []Data `json:"data"`
-		Meta Meta   `json:"meta"`
+		Data   []Data   `json:"data"`
+		Meta   Meta     `json:"meta"`
+		Links  *Links   `json:"links,omitempty"`
+		Fields []string `json:"-"` // the only fields of Data to return, all of them when empty
+
+		LastModified time.Time `json:"-"` // when the data was last changed, the zero time when it isn't known
 	}
 
 	//Data : Stores phone number information
 	Data struct {
-		Country     string `json:"country"`
-		State       string `json:"state"`
-		CountryCode string `json:"countryCode"`
-		PhoneNumber string `json:"phoneNumber"`
+		ID          int64       `json:"id"`
+		Name        string      `json:"name"`
+		Country     string      `json:"country"`
+		State       string      `json:"state"`
+		CountryCode string      `json:"countryCode"`
+		PhoneNumber string      `json:"phoneNumber"`
+		Highlights  *Highlights `json:"highlights,omitempty"`
+	}
+
+	//Highlights : The fields that matched a search, with the matched fragments wrapped in <mark> tags
+	Highlights struct {
+		Name        string `json:"name,omitempty"`
+		PhoneNumber string `json:"phoneNumber,omitempty"`
 	}
 
 	//Meta : contains pagination metadata
 	Meta struct {
 		CurrentPage string `json:"page"`
+		Offset      int    `json:"offset"`
+		Limit       int    `json:"limit"`
 		Next        bool   `json:"next"`
 		Prev        bool   `json:"prev"`
+		LastPage    int    `json:"-"` // 0 when the number of pages isn't known
+		Count       int    `json:"-"` // how many results there are over every page, only known along with LastPage
+	}
+
+	//Links : URLs of the pages around the current one, pages which don't exist are left out
+	Links struct {
+		Self  string `json:"self"`
+		First string `json:"first"`
+		Prev  string `json:"prev,omitempty"`
+		Next  string `json:"next,omitempty"`
+		Last  string `json:"last,omitempty"`
+	}
+
+	//PhoneNumberQuery : Describes what a client asked for when listing phone numbers
+	PhoneNumberQuery struct {
+		Filter     Filter
+		Sort       []SortKey
+		Pagination Pagination
+		Fields     []string // the fields of each result to return, all of them when empty
+	}
+
+	//SortKey : A field to order results by, results are ordered by every key in turn
+	SortKey struct {
+		Field      string
+		Descending bool
+	}
+
+	//Filter : Criteria used for narrowing down the phone numbers returned, an empty list matches everything
+	Filter struct {
+		Countries         []string
+		ExcludedCountries []string
+		Codes             []string // dialling codes of Countries the bracketed digits start with, resolved by the service to narrow down what's validated
+		States            []string
+		Search            Search
+	}
+
+	//Search : A search on either the national number or the customer's name, the zero value matches everything
+	Search struct {
+		Number string   // digits the national number should contain
+		Prefix bool     // the national number should start with Number rather than just contain it
+		Terms  []string // words in the customer's name should start with each of these terms
+	}
+
+	//Pagination : The results requested, the offset is where Page begins unless the client asked for an offset instead
+	Pagination struct {
+		Page   int
+		Offset int
+		Limit  int
+	}
+
+	//Record : A phone number as it is stored in the repository
+	Record struct {
+		ID    int64
+		Name  string
+		Phone string
+	}
+
+	//Cursor : Position to read records from
+	Cursor struct {
+		Sort   []SortKey // order to read records in, only stored fields can be used and ties are always broken by ID
+		After  *Record   // only records ordered after this one are read, nil reads from the start
+		Offset int
+		Limit  int
+	}
+
+	//Health : Whether the repository can be reached, along with the state of its connection pools
+	Health struct {
+		Status string               `json:"status"` // up or down
+		Error  string               `json:"error,omitempty"`
+		Pools  map[string]PoolStats `json:"pools,omitempty"` // by what the pool is used for, e.g. read or write
+	}
+
+	//PoolStats : The connections of a pool and how long queries waited for one
+	PoolStats struct {
+		MaxOpen      int    `json:"maxOpen"`
+		Open         int    `json:"open"`
+		InUse        int    `json:"inUse"`
+		Idle         int    `json:"idle"`
+		WaitCount    int64  `json:"waitCount"`    // the number of times a query waited for a free connection
+		WaitDuration string `json:"waitDuration"` // the time spent waiting for free connections in total
 	}
 )
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"assessment/model"
+	"context"
+)
+
+/*HealthChecker : A repository which can check whether it can be reached
+Repositories which don't implement it are assumed to always be up.
+*/
+type HealthChecker interface {
+	Health(ctx context.Context) model.Health
+}
@@ -2,29 +2,34 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	model "assessment/model"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
 
 // PhoneNumberRepository is an autogenerated mock type for the PhoneNumberRepository type
 type PhoneNumberRepository struct {
 	mock.Mock
 }
 
-// FetchPaginatedPhoneNumbers provides a mock function with given fields: offset, limit
-func (_m *PhoneNumberRepository) FetchPaginatedPhoneNumbers(offset int, limit int) ([]string, error) {
-	ret := _m.Called(offset, limit)
+// CountPhoneNumbers provides a mock function with given fields: ctx, filter
+func (_m *PhoneNumberRepository) CountPhoneNumbers(ctx context.Context, filter model.Filter) (int, error) {
+	ret := _m.Called(ctx, filter)
 
-	var r0 []string
-	if rf, ok := ret.Get(0).(func(int, int) []string); ok {
-		r0 = rf(offset, limit)
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, model.Filter) int); ok {
+		r0 = rf(ctx, filter)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]string)
-		}
+		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(int, int) error); ok {
-		r1 = rf(offset, limit)
+	if rf, ok := ret.Get(1).(func(context.Context, model.Filter) error); ok {
+		r1 = rf(ctx, filter)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -32,22 +37,43 @@ func (_m *PhoneNumberRepository) FetchPaginatedPhoneNumbers(offset int, limit in
 	return r0, r1
 }
 
-// FetchPaginatedPhoneNumbersByCode provides a mock function with given fields: code, offset, limit
-func (_m *PhoneNumberRepository) FetchPaginatedPhoneNumbersByCode(code string, offset int, limit int) ([]string, error) {
-	ret := _m.Called(code, offset, limit)
+// FetchPhoneNumbers provides a mock function with given fields: ctx, filter, cursor
+func (_m *PhoneNumberRepository) FetchPhoneNumbers(ctx context.Context, filter model.Filter, cursor model.Cursor) ([]model.Record, error) {
+	ret := _m.Called(ctx, filter, cursor)
 
-	var r0 []string
-	if rf, ok := ret.Get(0).(func(string, int, int) []string); ok {
-		r0 = rf(code, offset, limit)
+	var r0 []model.Record
+	if rf, ok := ret.Get(0).(func(context.Context, model.Filter, model.Cursor) []model.Record); ok {
+		r0 = rf(ctx, filter, cursor)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]string)
+			r0 = ret.Get(0).([]model.Record)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, int, int) error); ok {
-		r1 = rf(code, offset, limit)
+	if rf, ok := ret.Get(1).(func(context.Context, model.Filter, model.Cursor) error); ok {
+		r1 = rf(ctx, filter, cursor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LastModified provides a mock function with given fields: ctx
+func (_m *PhoneNumberRepository) LastModified(ctx context.Context) (time.Time, error) {
+	ret := _m.Called(ctx)
+
+	var r0 time.Time
+	if rf, ok := ret.Get(0).(func(context.Context) time.Time); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -55,6 +81,20 @@ func (_m *PhoneNumberRepository) FetchPaginatedPhoneNumbersByCode(code string, o
 	return r0, r1
 }
 
+// ScanPhoneNumbers provides a mock function with given fields: ctx, filter, cursor, yield
+func (_m *PhoneNumberRepository) ScanPhoneNumbers(ctx context.Context, filter model.Filter, cursor model.Cursor, yield func(model.Record) bool) error {
+	ret := _m.Called(ctx, filter, cursor, yield)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.Filter, model.Cursor, func(model.Record) bool) error); ok {
+		r0 = rf(ctx, filter, cursor, yield)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewPhoneNumberRepository interface {
 	mock.TestingT
 	Cleanup(func())
@@ -0,0 +1,35 @@
+package repository
+
+/*CompareNoCase : Compares names the way every repository orders them, which is how SQLite's NOCASE collation does
+Only the ASCII letters are folded to lower case, everything else is compared byte by byte. Returns -1, 0 or 1.
+*/
+func CompareNoCase(a, b string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		x, y := lowerASCII(a[i]), lowerASCII(b[i])
+
+		if x != y {
+			return compare(int(x), int(y))
+		}
+	}
+
+	return compare(len(a), len(b))
+}
+
+func lowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+
+	return c
+}
+
+func compare(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
@@ -1,6 +1,21 @@
 package repository
 
+import (
+	"assessment/model"
+	"context"
+	"time"
+)
+
+/*PhoneNumberRepository : Where the phone numbers are stored
+Queries stop as soon as their context is done, with the error of the context.
+ScanPhoneNumbers reads like FetchPhoneNumbers, but hands every record to yield as soon as it's read instead of collecting
+them, so scans over the whole table don't hold it in memory. A limit of 0 or less reads every record after the offset, and
+the scan stops early once yield returns false.
+LastModified is asked on every query, so it gives up with the error of the context like the queries do.
+*/
 type PhoneNumberRepository interface {
-	FetchPaginatedPhoneNumbers(offset, limit int) ([]string, error)
-	FetchPaginatedPhoneNumbersByCode(code string, offset, limit int) ([]string, error)
+	FetchPhoneNumbers(ctx context.Context, filter model.Filter, cursor model.Cursor) ([]model.Record, error)
+	CountPhoneNumbers(ctx context.Context, filter model.Filter) (int, error)
+	ScanPhoneNumbers(ctx context.Context, filter model.Filter, cursor model.Cursor, yield func(model.Record) bool) error
+	LastModified(ctx context.Context) (time.Time, error)
 }
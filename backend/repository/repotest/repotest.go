@@ -0,0 +1,340 @@
+/*Package repotest : A conformance suite every PhoneNumberRepository has to pass
+The service relies on every repository filtering, ordering and paging records the same way, so they can be swapped with
+DB_DRIVER without the results changing. Implementations run the suite from their own tests:
+
+	func TestRepo_Conformance(t *testing.T) {
+		repotest.Run(t, func(t *testing.T, records []model.Record) repository.PhoneNumberRepository {
+			return newTestRepo(t, records)
+		})
+	}
+*/
+package repotest
+
+import (
+	"assessment/model"
+	"assessment/repository"
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+/*Factory : Creates a repository holding exactly the records provided, releasing it when the test ends
+An empty name stands for a missing one and is read back as empty.
+*/
+type Factory func(t *testing.T, records []model.Record) repository.PhoneNumberRepository
+
+/*Records : The records every test starts from
+Names only use ASCII letters, which every database folds to lower case the same way, and two of them only differ in
+case so ties have to be broken by the ID. The codes 1 and 1242 overlap, as do the national numbers of 212 and 237.
+*/
+var Records = []model.Record{
+	{ID: 1, Name: "Yosaf Karrouch", Phone: "(212) 698054317"},
+	{ID: 2, Name: "walid hammadi", Phone: "(212) 6007989253"},
+	{ID: 3, Name: "", Phone: "(237) 697151594"},
+	{ID: 4, Name: "Ann Lee", Phone: "(237) 677046616"},
+	{ID: 5, Name: "ann lee", Phone: "(1) 2025550143"},
+	{ID: 6, Name: "Bob Smith", Phone: "(1242) 3595555"},
+	{ID: 7, Name: "Zed", Phone: "(258) 847651504"},
+	{ID: 8, Name: "Ann O'Brien", Phone: "(251) 914701723"},
+}
+
+// orders : the IDs of Records in every order the repositories support
+var orders = []struct {
+	sort []model.SortKey
+	ids  []int64
+}{
+	{nil, []int64{1, 2, 3, 4, 5, 6, 7, 8}},
+	{[]model.SortKey{{Field: "id", Descending: true}}, []int64{8, 7, 6, 5, 4, 3, 2, 1}},
+	// missing names come first, and names equal but for their case are ordered by ID
+	{[]model.SortKey{{Field: "name"}}, []int64{3, 4, 5, 8, 6, 2, 1, 7}},
+	{[]model.SortKey{{Field: "name", Descending: true}}, []int64{7, 1, 2, 6, 8, 4, 5, 3}},
+	{[]model.SortKey{{Field: "name"}, {Field: "id", Descending: true}}, []int64{3, 5, 4, 8, 6, 2, 1, 7}},
+	// phones are compared byte by byte, so (1) comes before (1242)
+	{[]model.SortKey{{Field: "phone"}}, []int64{5, 6, 2, 1, 4, 3, 8, 7}},
+}
+
+//Run : Runs every conformance test against repositories created by the factory
+func Run(t *testing.T, newRepo Factory) {
+	t.Run("Empty", func(t *testing.T) { testEmpty(t, newRepo) })
+	t.Run("Pagination", func(t *testing.T) { testPagination(t, newRepo) })
+	t.Run("Codes", func(t *testing.T) { testCodes(t, newRepo) })
+	t.Run("Search", func(t *testing.T) { testSearch(t, newRepo) })
+	t.Run("Ordering", func(t *testing.T) { testOrdering(t, newRepo) })
+	t.Run("NonASCIINames", func(t *testing.T) { testNonASCIINames(t, newRepo) })
+	t.Run("After", func(t *testing.T) { testAfter(t, newRepo) })
+	t.Run("Scan", func(t *testing.T) { testScan(t, newRepo) })
+	t.Run("Errors", func(t *testing.T) { testErrors(t, newRepo) })
+}
+
+//fetch : fetches the records and returns their IDs
+func fetch(t *testing.T, repo repository.PhoneNumberRepository, filter model.Filter, cursor model.Cursor) []int64 {
+	t.Helper()
+
+	records, err := repo.FetchPhoneNumbers(context.Background(), filter, cursor)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	ids := []int64{}
+
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+
+	return ids
+}
+
+//scan : scans the records and returns their IDs
+func scan(t *testing.T, repo repository.PhoneNumberRepository, filter model.Filter, cursor model.Cursor) []int64 {
+	t.Helper()
+
+	ids := []int64{}
+
+	err := repo.ScanPhoneNumbers(context.Background(), filter, cursor, func(record model.Record) bool {
+		ids = append(ids, record.ID)
+		return true
+	})
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	return ids
+}
+
+func count(t *testing.T, repo repository.PhoneNumberRepository, filter model.Filter) int {
+	t.Helper()
+
+	count, err := repo.CountPhoneNumbers(context.Background(), filter)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	return count
+}
+
+func testEmpty(t *testing.T, newRepo Factory) {
+	repo := newRepo(t, nil)
+
+	require.Empty(t, fetch(t, repo, model.Filter{}, model.Cursor{Limit: 10}))
+	require.Empty(t, fetch(t, repo, model.Filter{}, model.Cursor{Sort: []model.SortKey{{Field: "name"}}, Limit: 10, After: &Records[0]}))
+	require.Equal(t, 0, count(t, repo, model.Filter{}))
+
+	_, err := repo.LastModified(context.Background())
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+}
+
+func testPagination(t *testing.T, newRepo Factory) {
+	repo := newRepo(t, Records)
+
+	// records are read whole, names included
+	records, err := repo.FetchPhoneNumbers(context.Background(), model.Filter{}, model.Cursor{Limit: 3})
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Equal(t, Records[:3], records)
+
+	require.Equal(t, []int64{4, 5, 6}, fetch(t, repo, model.Filter{}, model.Cursor{Offset: 3, Limit: 3}))
+
+	// the last page is short, and pages past it are empty
+	require.Equal(t, []int64{7, 8}, fetch(t, repo, model.Filter{}, model.Cursor{Offset: 6, Limit: 3}))
+	require.Empty(t, fetch(t, repo, model.Filter{}, model.Cursor{Offset: 8, Limit: 3}))
+	require.Empty(t, fetch(t, repo, model.Filter{}, model.Cursor{Offset: 100, Limit: 3}))
+	require.Empty(t, fetch(t, repo, model.Filter{}, model.Cursor{Limit: 0}))
+	require.Equal(t, []int64{8}, fetch(t, repo, model.Filter{}, model.Cursor{Offset: 7, Limit: 1}))
+	require.Equal(t, []int64{1, 2, 3, 4, 5, 6, 7, 8}, fetch(t, repo, model.Filter{}, model.Cursor{Limit: 100}))
+
+	// pages of a filter count from its first match
+	filter := model.Filter{Codes: []string{"212", "237"}}
+	require.Equal(t, []int64{3, 4}, fetch(t, repo, filter, model.Cursor{Offset: 2, Limit: 3}))
+	require.Equal(t, 4, count(t, repo, filter))
+	require.Equal(t, len(Records), count(t, repo, model.Filter{}))
+}
+
+func testCodes(t *testing.T, newRepo Factory) {
+	repo := newRepo(t, Records)
+
+	for _, test := range []struct {
+		filter model.Filter
+		ids    []int64
+	}{
+		{model.Filter{Codes: []string{"212"}}, []int64{1, 2}},
+		{model.Filter{Codes: []string{"212", "237"}}, []int64{1, 2, 3, 4}},
+		// a code matches every number whose bracketed digits start with it, (1242) is the Bahamas' code 1 and area code 242
+		{model.Filter{Codes: []string{"1"}}, []int64{5, 6}},
+		{model.Filter{Codes: []string{"1242"}}, []int64{6}},
+		{model.Filter{Codes: []string{"12"}}, []int64{6}},
+		{model.Filter{Codes: []string{"21"}}, []int64{1, 2}},
+		{model.Filter{Codes: []string{"999"}}, []int64{}},
+		// codes are matched literally
+		{model.Filter{Codes: []string{"2_2"}}, []int64{}},
+		{model.Filter{Codes: []string{"%"}}, []int64{}},
+	} {
+		require.Equal(t, test.ids, fetch(t, repo, test.filter, model.Cursor{Limit: 100}), "%+v", test.filter)
+		require.Equal(t, len(test.ids), count(t, repo, test.filter), "%+v", test.filter)
+	}
+}
+
+func testSearch(t *testing.T, newRepo Factory) {
+	repo := newRepo(t, Records)
+
+	for _, test := range []struct {
+		search model.Search
+		ids    []int64
+	}{
+		// numbers are searched without their code
+		{model.Search{Number: "555"}, []int64{5, 6}},
+		{model.Search{Number: "69"}, []int64{1, 3}},
+		{model.Search{Number: "69", Prefix: true}, []int64{1, 3}},
+		{model.Search{Number: "555", Prefix: true}, []int64{}},
+		{model.Search{Number: "212"}, []int64{}},
+		{model.Search{Number: "5_5"}, []int64{}},
+		// every term has to start a word of the name, whatever its case
+		{model.Search{Terms: []string{"ann"}}, []int64{4, 5, 8}},
+		{model.Search{Terms: []string{"lee", "ann"}}, []int64{4, 5}},
+		{model.Search{Terms: []string{"hammadi"}}, []int64{2}},
+		{model.Search{Terms: []string{"smi"}}, []int64{6}},
+		{model.Search{Terms: []string{"mith"}}, []int64{}},
+		{model.Search{Terms: []string{"ann", "smith"}}, []int64{}},
+	} {
+		filter := model.Filter{Search: test.search}
+
+		require.Equal(t, test.ids, fetch(t, repo, filter, model.Cursor{Limit: 100}), "%+v", test.search)
+		require.Equal(t, len(test.ids), count(t, repo, filter), "%+v", test.search)
+	}
+
+	// searches narrow down the codes
+	filter := model.Filter{Codes: []string{"237"}, Search: model.Search{Number: "69"}}
+	require.Equal(t, []int64{3}, fetch(t, repo, filter, model.Cursor{Limit: 100}))
+}
+
+func testOrdering(t *testing.T, newRepo Factory) {
+	repo := newRepo(t, Records)
+
+	for _, order := range orders {
+		require.Equal(t, order.ids, fetch(t, repo, model.Filter{}, model.Cursor{Sort: order.sort, Limit: 100}), "%+v", order.sort)
+
+		// offsets count in the order of the sort
+		require.Equal(t, order.ids[2:5], fetch(t, repo, model.Filter{}, model.Cursor{Sort: order.sort, Offset: 2, Limit: 3}), "%+v", order.sort)
+	}
+}
+
+/*testNonASCIINames : only the ASCII letters of names are folded to lower case, like SQLite's NOCASE does
+Other letters are compared byte by byte whatever their case, so É (C3 89) comes before é (C3 A9) and both after z.
+*/
+func testNonASCIINames(t *testing.T, newRepo Factory) {
+	records := []model.Record{
+		{ID: 1, Name: "émile", Phone: "(212) 698054317"},
+		{ID: 2, Name: "Émile", Phone: "(212) 6007989253"},
+		{ID: 3, Name: "Zed", Phone: "(237) 697151594"},
+		{ID: 4, Name: "ava", Phone: "(237) 677046616"},
+	}
+
+	repo := newRepo(t, records)
+	byName := []model.SortKey{{Field: "name"}}
+
+	require.Equal(t, []int64{4, 3, 2, 1}, fetch(t, repo, model.Filter{}, model.Cursor{Sort: byName, Limit: 10}))
+	require.Equal(t, []int64{1}, fetch(t, repo, model.Filter{}, model.Cursor{Sort: byName, After: &records[1], Limit: 10}))
+	require.Equal(t, []int64{4, 3, 2, 1}, scan(t, repo, model.Filter{}, model.Cursor{Sort: byName}))
+}
+
+func testAfter(t *testing.T, newRepo Factory) {
+	repo := newRepo(t, Records)
+
+	byID := make(map[int64]model.Record)
+
+	for _, record := range Records {
+		byID[record.ID] = record
+	}
+
+	// reading page after page, each starting after the last record of the one before, reads every record once
+	for _, order := range orders {
+		var (
+			ids   []int64
+			after *model.Record
+		)
+
+		for {
+			page := fetch(t, repo, model.Filter{}, model.Cursor{Sort: order.sort, After: after, Limit: 3})
+
+			if len(page) == 0 {
+				break
+			}
+
+			ids = append(ids, page...)
+			last := byID[page[len(page)-1]]
+			after = &last
+		}
+
+		require.Equal(t, order.ids, ids, "%+v", order.sort)
+	}
+
+	// the record a page starts after doesn't have to match the filter
+	filter := model.Filter{Codes: []string{"212", "237"}}
+	require.Equal(t, []int64{3, 4}, fetch(t, repo, filter, model.Cursor{After: &Records[1], Limit: 100}))
+	require.Equal(t, []int64{2, 1}, fetch(t, repo, filter, model.Cursor{Sort: orders[2].sort, After: &Records[5], Limit: 100}))
+
+	// and offsets count from it
+	require.Equal(t, []int64{6, 7}, fetch(t, repo, model.Filter{}, model.Cursor{After: &Records[3], Offset: 1, Limit: 2}))
+}
+
+func testScan(t *testing.T, newRepo Factory) {
+	require.Empty(t, scan(t, newRepo(t, nil), model.Filter{}, model.Cursor{}))
+
+	repo := newRepo(t, Records)
+
+	// records are read whole, in the order of the sort, without a limit
+	var records []model.Record
+
+	err := repo.ScanPhoneNumbers(context.Background(), model.Filter{}, model.Cursor{}, func(record model.Record) bool {
+		records = append(records, record)
+		return true
+	})
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Equal(t, Records, records)
+
+	for _, order := range orders {
+		require.Equal(t, order.ids, scan(t, repo, model.Filter{}, model.Cursor{Sort: order.sort}), "%+v", order.sort)
+		require.Equal(t, order.ids[3:], scan(t, repo, model.Filter{}, model.Cursor{Sort: order.sort, Offset: 3}), "%+v", order.sort)
+		require.Equal(t, order.ids[1:3], scan(t, repo, model.Filter{}, model.Cursor{Sort: order.sort, Offset: 1, Limit: 2}), "%+v", order.sort)
+	}
+
+	filter := model.Filter{Codes: []string{"212", "237"}}
+	require.Equal(t, []int64{3, 4}, scan(t, repo, filter, model.Cursor{After: &Records[1]}))
+	require.Empty(t, scan(t, repo, filter, model.Cursor{Offset: 100}))
+
+	// the scan stops as soon as yield asks it to
+	var ids []int64
+
+	err = repo.ScanPhoneNumbers(context.Background(), model.Filter{}, model.Cursor{}, func(record model.Record) bool {
+		ids = append(ids, record.ID)
+		return len(ids) < 3
+	})
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Equal(t, []int64{1, 2, 3}, ids)
+
+	// the repository can be queried again from yield without the scan holding anything up
+	err = repo.ScanPhoneNumbers(context.Background(), model.Filter{}, model.Cursor{Limit: 2}, func(record model.Record) bool {
+		require.Equal(t, len(Records), count(t, repo, model.Filter{}))
+		return true
+	})
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+}
+
+func testErrors(t *testing.T, newRepo Factory) {
+	repo := newRepo(t, Records)
+
+	// only stored fields can be sorted by
+	_, err := repo.FetchPhoneNumbers(context.Background(), model.Filter{}, model.Cursor{Sort: []model.SortKey{{Field: "state"}}, Limit: 10})
+	require.Error(t, err)
+
+	_, err = repo.FetchPhoneNumbers(context.Background(), model.Filter{}, model.Cursor{Sort: []model.SortKey{{Field: "country"}}, After: &Records[0], Limit: 10})
+	require.Error(t, err)
+
+	// queries of requests which are done fail with the error of their context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = repo.FetchPhoneNumbers(ctx, model.Filter{}, model.Cursor{Limit: 10})
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, err = repo.CountPhoneNumbers(ctx, model.Filter{})
+	require.ErrorIs(t, err, context.Canceled)
+
+	err = repo.ScanPhoneNumbers(ctx, model.Filter{}, model.Cursor{}, func(model.Record) bool { return true })
+	require.ErrorIs(t, err, context.Canceled)
+
+	err = repo.ScanPhoneNumbers(context.Background(), model.Filter{}, model.Cursor{Sort: []model.SortKey{{Field: "state"}}}, func(model.Record) bool { return true })
+	require.Error(t, err)
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"assessment/model"
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//flight : a query being run, which identical queries arriving in the meantime wait for
+type flight struct {
+	done    chan struct{}
+	result  model.Result
+	err     error
+	dups    int                // the number of queries waiting for this one
+	waiting int                // the number of callers still waiting for the result, the first one included
+	cancel  context.CancelFunc // stops the query once nobody is waiting for it
+}
+
+/*queryGroup : merges identical queries which run at the same time into one
+The first query runs and the ones arriving before it finishes get its result, so a burst of identical requests only
+reads the repository once. The result is shared, so callers mustn't modify the data in it.
+The query runs with a context of its own rather than the context of the caller which started it, so that caller going
+away doesn't fail the others. It's only canceled once every caller waiting for it has gone.
+*/
+type queryGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+func newQueryGroup() *queryGroup {
+	return &queryGroup{flights: make(map[string]*flight)}
+}
+
+/*do : runs the query unless an identical one is already running, in which case its result is returned once it's done
+Returns as soon as the context of the caller is done, with the error of its context. A query which is canceled because
+its last caller went away is forgotten straight away, so the queries arriving after it start a new one instead of
+sharing its cancellation.
+*/
+func (g *queryGroup) do(ctx context.Context, key string, run func(ctx context.Context) (model.Result, error)) (model.Result, error) {
+	g.mu.Lock()
+
+	f, ok := g.flights[key]
+
+	if ok {
+		f.dups++
+	} else {
+		flightCtx, cancel := context.WithCancel(context.Background())
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		g.flights[key] = f
+
+		go g.run(flightCtx, key, f, run)
+	}
+
+	f.waiting++
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.result, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiting--
+
+		if f.waiting == 0 {
+			f.cancel()
+			g.forget(key, f)
+		}
+
+		g.mu.Unlock()
+
+		return model.Result{}, ctx.Err()
+	}
+}
+
+//run : runs the query of a flight, then wakes up every caller waiting for it
+func (g *queryGroup) run(ctx context.Context, key string, f *flight, run func(ctx context.Context) (model.Result, error)) {
+	// the flight is finished even if the query panics, otherwise every query waiting for it would hang
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Query %q panicked: %v\n%s", key, r, debug.Stack())
+			f.err = fmt.Errorf("query panicked: %v", r)
+		}
+
+		g.mu.Lock()
+		g.forget(key, f)
+		g.mu.Unlock()
+
+		f.cancel()
+		close(f.done)
+	}()
+
+	f.result, f.err = run(ctx)
+}
+
+//forget : removes the flight of the key unless another has replaced it already, the group must be locked
+func (g *queryGroup) forget(key string, f *flight) {
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+}
+
+// resultCacheSize : the most results kept at once, there are only ever a few hot queries within a TTL
+const resultCacheSize = 1000
+
+//cachedResult : a result along with when it stops being served
+type cachedResult struct {
+	result  model.Result
+	expires time.Time
+}
+
+/*resultCache : keeps the results of queries for a short time
+Results are kept for a version of the data, when the version changes every result is dropped. Every time results are
+dropped the generation of the cache moves on, so results of queries which started before can't be put back.
+*/
+type resultCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	now        func() time.Time
+	version    time.Time
+	generation uint64
+	entries    map[string]cachedResult
+}
+
+func newResultCache(ttl time.Duration) *resultCache {
+	return &resultCache{ttl: ttl, now: time.Now, entries: make(map[string]cachedResult)}
+}
+
+//get : the result cached for the query, when it hasn't expired
+func (c *resultCache) get(key string) (model.Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+
+	if !ok {
+		return model.Result{}, false
+	}
+
+	if !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		return model.Result{}, false
+	}
+
+	return entry.result, true
+}
+
+//put : caches the result of the query, as long as no results were dropped since the query started
+func (c *resultCache) put(key string, result model.Result, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// the data changed while the query was running
+	if generation != c.generation {
+		return
+	}
+
+	now := c.now()
+
+	if len(c.entries) >= resultCacheSize {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+
+	// every result is still fresh, one of them has to make room
+	if len(c.entries) >= resultCacheSize {
+		for k := range c.entries {
+			delete(c.entries, k)
+			break
+		}
+	}
+
+	c.entries[key] = cachedResult{result: result, expires: now.Add(c.ttl)}
+}
+
+/*sync : records the current version of the data, dropping every result when it has changed since the last call
+Returns the generation results read from this version of the data have to be put with.
+*/
+func (c *resultCache) sync(version time.Time) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !version.Equal(c.version) {
+		c.version = version
+		c.clear()
+	}
+
+	return c.generation
+}
+
+func (c *resultCache) clear() {
+	c.generation++
+	c.entries = make(map[string]cachedResult)
+}
+
+/*queryKey : identifies the result of a query
+Lists which are matched as sets are sorted, so ?country=uganda,cameroon and ?country=cameroon,uganda share a result.
+Countries are keyed by name rather than code, since countries sharing a code don't share their numbers.
+The sort keys aren't since their order decides the order of the results.
+*/
+func queryKey(query model.PhoneNumberQuery) string {
+	filter := query.Filter
+
+	return fmt.Sprintf("%s|%s|%s|%s|%t|%s|%v|%d|%d|%s",
+		sortedFold(filter.Countries), sortedFold(filter.ExcludedCountries), sorted(filter.States),
+		filter.Search.Number, filter.Search.Prefix, sorted(filter.Search.Terms),
+		query.Sort, query.Pagination.Offset, query.Pagination.Limit, sorted(query.Fields))
+}
+
+//sortedFold : like sorted, ignoring case as countries are looked up by name
+func sortedFold(list []string) string {
+	folded := make([]string, 0, len(list))
+
+	for _, entry := range list {
+		folded = append(folded, strings.ToLower(entry))
+	}
+
+	return sorted(folded)
+}
+
+func sorted(list []string) string {
+	list = append([]string{}, list...)
+	sort.Strings(list)
+
+	return strings.Join(list, ",")
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"assessment/model"
+	repoMock "assessment/repository/mock"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//waitForDups : blocks until the given number of queries wait on the flight of the key
+func waitForDups(t *testing.T, g *queryGroup, key string, dups int) {
+	deadline := time.Now().Add(5 * time.Second)
+
+	for time.Now().Before(deadline) {
+		g.mu.Lock()
+		f, ok := g.flights[key]
+		joined := ok && f.dups == dups
+		g.mu.Unlock()
+
+		if joined {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("%d queries never joined the flight of %q", dups, key)
+}
+
+func TestQueryGroup_Do(t *testing.T) {
+	var (
+		g       = newQueryGroup()
+		runs    int32
+		release = make(chan struct{})
+		wg      sync.WaitGroup
+		results = make([]model.Result, 10)
+	)
+
+	run := func(context.Context) (model.Result, error) {
+		atomic.AddInt32(&runs, 1)
+		<-release
+
+		return model.Result{Meta: model.Meta{CurrentPage: "1"}}, nil
+	}
+
+	for i := range results {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			var err error
+
+			results[i], err = g.do(context.Background(), "key", run)
+			require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+		}(i)
+
+		// the first query has to be running before the others arrive
+		if i == 0 {
+			waitForDups(t, g, "key", 0)
+		}
+	}
+
+	waitForDups(t, g, "key", len(results)-1)
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, int32(1), runs)
+
+	for _, result := range results {
+		require.Equal(t, "1", result.Meta.CurrentPage)
+	}
+
+	// once finished, the next query runs again
+	_, _ = g.do(context.Background(), "key", func(context.Context) (model.Result, error) {
+		atomic.AddInt32(&runs, 1)
+		return model.Result{}, nil
+	})
+
+	require.Equal(t, int32(2), runs)
+	require.Empty(t, g.flights)
+}
+
+func TestNumberService_CoalescesIdenticalQueries(t *testing.T) {
+	mockRepo := new(repoMock.PhoneNumberRepository)
+	mockRepo.On("LastModified", mock.Anything).Return(time.Time{}, nil)
+	release := make(chan struct{})
+
+	scan := scanTable("(237) 697151594", "(237) 100000002", "(237) 100000003")
+
+	mockRepo.On("ScanPhoneNumbers", mock.Anything, model.Filter{States: []string{"NOK"}}, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			<-release
+			scan(args)
+		}).
+		Return(nil)
+
+	svc := NewNumberService(NewValidator(), mockRepo)
+
+	query := model.PhoneNumberQuery{
+		Filter:     model.Filter{States: []string{"NOK"}},
+		Pagination: model.Pagination{Page: 1, Offset: 0, Limit: 5},
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			result, err := svc.Query(context.Background(), query)
+			require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+			require.Len(t, result.Data, 2)
+		}()
+
+		if i == 0 {
+			waitForDups(t, svc.flights, queryKey(query), 0)
+		}
+	}
+
+	waitForDups(t, svc.flights, queryKey(query), 4)
+	close(release)
+	wg.Wait()
+
+	// the filtered scan only read the repository once for all five requests
+	mockRepo.AssertNumberOfCalls(t, "ScanPhoneNumbers", 1)
+}
+
+func TestNumberService_ResultCache(t *testing.T) {
+	mockRepo := new(repoMock.PhoneNumberRepository)
+
+	before := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	after := before.Add(time.Hour)
+
+	mockRepo.On("FetchPhoneNumbers", mock.Anything, model.Filter{}, mock.Anything).Return(table("(237) 697151594"), nil)
+	mockRepo.On("CountPhoneNumbers", mock.Anything, model.Filter{}).Return(1, nil)
+	mockRepo.On("LastModified", mock.Anything).Return(before, nil).Times(4)
+	mockRepo.On("LastModified", mock.Anything).Return(after, nil)
+
+	svc := NewNumberService(NewValidator(), mockRepo, WithResultCache(time.Minute))
+
+	now := before
+	svc.results.now = func() time.Time { return now }
+
+	query := model.PhoneNumberQuery{Pagination: model.Pagination{Page: 1, Offset: 0, Limit: 5}}
+
+	for i := 0; i < 3; i++ {
+		_, err := svc.Query(context.Background(), query)
+		require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	}
+
+	// the same query within the TTL is answered from the cache
+	mockRepo.AssertNumberOfCalls(t, "FetchPhoneNumbers", 1)
+
+	// another page is another query
+	_, err := svc.Query(context.Background(), model.PhoneNumberQuery{Pagination: model.Pagination{Page: 2, Offset: 5, Limit: 5}})
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	mockRepo.AssertNumberOfCalls(t, "FetchPhoneNumbers", 2)
+
+	// the data changes, so the cached result is dropped even though it hasn't expired
+	_, err = svc.Query(context.Background(), query)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	mockRepo.AssertNumberOfCalls(t, "FetchPhoneNumbers", 3)
+
+	_, err = svc.Query(context.Background(), query)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	mockRepo.AssertNumberOfCalls(t, "FetchPhoneNumbers", 3)
+
+	// the result expires
+	now = now.Add(time.Minute)
+
+	_, err = svc.Query(context.Background(), query)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	mockRepo.AssertNumberOfCalls(t, "FetchPhoneNumbers", 4)
+}
+
+func TestQueryKey(t *testing.T) {
+	query := func(countries []string, sort []model.SortKey) model.PhoneNumberQuery {
+		return model.PhoneNumberQuery{
+			Filter:     model.Filter{Countries: countries},
+			Sort:       sort,
+			Pagination: model.Pagination{Page: 1, Limit: 5},
+		}
+	}
+
+	byName := []model.SortKey{{Field: "name"}}
+	byNameThenID := []model.SortKey{{Field: "name"}, {Field: "id", Descending: true}}
+	byIDThenName := []model.SortKey{{Field: "id", Descending: true}, {Field: "name"}}
+
+	// sets of values are the same whatever their order, sort keys aren't
+	require.Equal(t, queryKey(query([]string{"cameroon", "uganda"}, byName)), queryKey(query([]string{"Uganda", "cameroon"}, byName)))
+	require.NotEqual(t, queryKey(query(nil, byNameThenID)), queryKey(query(nil, byIDThenName)))
+	require.NotEqual(t, queryKey(query([]string{"cameroon"}, nil)), queryKey(query([]string{"uganda"}, nil)))
+
+	// countries sharing a code don't share their numbers
+	require.NotEqual(t, queryKey(query([]string{"canada"}, nil)), queryKey(query([]string{"united states"}, nil)))
+}
+
+func TestQueryGroup_DoCanceled(t *testing.T) {
+	var (
+		g       = newQueryGroup()
+		release = make(chan struct{})
+		started = make(chan context.Context, 1)
+		stopped = make(chan error, 1)
+	)
+
+	run := func(ctx context.Context) (model.Result, error) {
+		started <- ctx
+
+		select {
+		case <-release:
+			return model.Result{Meta: model.Meta{CurrentPage: "1"}}, nil
+		case <-ctx.Done():
+			stopped <- ctx.Err()
+			return model.Result{}, ctx.Err()
+		}
+	}
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	second, cancelSecond := context.WithCancel(context.Background())
+
+	defer cancelSecond()
+
+	firstErr := make(chan error, 1)
+
+	go func() {
+		_, err := g.do(first, "key", run)
+		firstErr <- err
+	}()
+
+	flightCtx := <-started
+
+	secondResult := make(chan model.Result, 1)
+
+	go func() {
+		result, err := g.do(second, "key", run)
+		require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+		secondResult <- result
+	}()
+
+	waitForDups(t, g, "key", 1)
+
+	// the caller which started the query goes away, the query keeps running for the other one
+	cancelFirst()
+	require.Equal(t, context.Canceled, <-firstErr)
+	require.NoError(t, flightCtx.Err())
+
+	close(release)
+	require.Equal(t, "1", (<-secondResult).Meta.CurrentPage)
+
+	// once every caller is gone, so is the query
+	third, cancelThird := context.WithCancel(context.Background())
+	release = make(chan struct{})
+
+	go func() {
+		_, _ = g.do(third, "key", run)
+	}()
+
+	<-started
+	cancelThird()
+
+	require.Equal(t, context.Canceled, <-stopped)
+}
+
+//TestQueryGroup_DoAfterCancel : a query arriving while a canceled one is still winding down runs again instead of failing
+func TestQueryGroup_DoAfterCancel(t *testing.T) {
+	var (
+		g       = newQueryGroup()
+		release = make(chan struct{})
+		started = make(chan struct{}, 2)
+	)
+
+	// the canceled query only notices once it's released
+	run := func(ctx context.Context) (model.Result, error) {
+		started <- struct{}{}
+		<-release
+
+		return model.Result{Meta: model.Meta{CurrentPage: "1"}}, ctx.Err()
+	}
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+
+	go func() {
+		_, err := g.do(first, "key", run)
+		firstErr <- err
+	}()
+
+	<-started
+	cancelFirst()
+	require.Equal(t, context.Canceled, <-firstErr)
+
+	// the query is still running, but nobody can join it anymore
+	g.mu.Lock()
+	require.Empty(t, g.flights)
+	g.mu.Unlock()
+
+	second := make(chan error, 1)
+
+	go func() {
+		result, err := g.do(context.Background(), "key", run)
+
+		if err == nil && result.Meta.CurrentPage != "1" {
+			err = fmt.Errorf("got the result of page %q", result.Meta.CurrentPage)
+		}
+
+		second <- err
+	}()
+
+	<-started
+
+	// the canceled query finishing doesn't take the new one with it
+	close(release)
+	require.NoError(t, <-second)
+}
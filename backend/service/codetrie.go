@@ -0,0 +1,153 @@
+package service
+
+import (
+	"regexp"
+)
+
+/*rule : how the numbers of a country are recognized, compiled once when the rule is set
+Rules set by hand match the whole number with a regular expression, the others check the national number against the
+country's numbering plan.
+*/
+type rule struct {
+	name     string
+	code     string         // the dialling code, without the +
+	plusCode string         // the dialling code as returned to clients, e.g. +237
+	regex    *regexp.Regexp // matches the whole number written as (code) national number, capturing both
+	plan     *territory     // used when there's no regex
+}
+
+//match : checks the number, returning the national number of a valid one
+func (r *rule) match(phone, number string) (string, bool) {
+	if r.regex == nil {
+		return number, r.plan.numberType(number) != ""
+	}
+
+	// the submatches of the regular expression are the country code and phone number (without country code)
+	if subMatch := r.regex.FindStringSubmatch(phone); subMatch != nil {
+		return subMatch[2], true
+	}
+
+	return number, false
+}
+
+//leads : checks whether the national number starts with the digits telling the country apart from others sharing its code
+func (r *rule) leads(number string) bool {
+	return r.plan != nil && r.plan.leads(number)
+}
+
+//hasLeadingDigits : whether the country is told apart from others sharing its code by how its numbers start
+func (r *rule) hasLeadingDigits() bool {
+	return r.plan != nil && r.plan.leadingDigits != nil
+}
+
+/*codeTrie : Finds the rules of the longest dialling code a number starts with, one digit at a time
+Codes can be prefixes of each other (1 and 1242), so a lookup walks as far down the digits as the trie goes and keeps the
+deepest rules it passed. Looking a number up costs at most the length of the longest code, however many countries there are.
+Several countries can share a code (the United States and Canada share 1), their rules are kept in the order they're
+checked in.
+*/
+type codeTrie struct {
+	root codeNode
+}
+
+type codeNode struct {
+	children [10]*codeNode
+	rules    []*rule // empty when no country has exactly this code
+}
+
+//add : registers the rule under its code, checked before the others sharing it when first is set and after them otherwise
+func (t *codeTrie) add(r *rule, first bool) {
+	node := &t.root
+
+	for _, digit := range r.code {
+		next := node.children[digit-'0']
+
+		if next == nil {
+			next = &codeNode{}
+			node.children[digit-'0'] = next
+		}
+
+		node = next
+	}
+
+	if first {
+		node.rules = append([]*rule{r}, node.rules...)
+	} else {
+		node.rules = append(node.rules, r)
+	}
+}
+
+//remove : forgets the rule, leaving the nodes behind as they're cheap and codes rarely change
+func (t *codeTrie) remove(r *rule) {
+	node := t.node(r.code)
+
+	if node == nil {
+		return
+	}
+
+	for index, other := range node.rules {
+		if other == r {
+			node.rules = append(node.rules[:index:index], node.rules[index+1:]...)
+			return
+		}
+	}
+}
+
+//rules : the rules registered under exactly the code
+func (t *codeTrie) rules(code string) []*rule {
+	if node := t.node(code); node != nil {
+		return node.rules
+	}
+
+	return nil
+}
+
+func (t *codeTrie) node(code string) *codeNode {
+	node := &t.root
+
+	for _, digit := range code {
+		if node = node.children[digit-'0']; node == nil {
+			return nil
+		}
+	}
+
+	return node
+}
+
+/*longest : The rules of the longest code the digits start with, along with how many digits that code takes
+Only the leading digits are looked at, the walk stops at the first character which isn't one.
+*/
+func (t *codeTrie) longest(digits string) ([]*rule, int) {
+	var (
+		found  []*rule
+		length int
+		node   = &t.root
+	)
+
+	for index := 0; index < len(digits) && isDigit(digits[index]); index++ {
+		if node = node.children[digits[index]-'0']; node == nil {
+			break
+		}
+
+		if len(node.rules) > 0 {
+			found, length = node.rules, index+1
+		}
+	}
+
+	return found, length
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+//isCode : checks that a dialling code is made of digits only, which is all the trie can hold
+func isCode(code string) bool {
+	for index := 0; index < len(code); index++ {
+		if !isDigit(code[index]) {
+			return false
+		}
+	}
+
+	return code != ""
+}
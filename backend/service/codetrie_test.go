@@ -0,0 +1,76 @@
+package service
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCodeTrie_Longest(t *testing.T) {
+	var trie codeTrie
+
+	rules := make(map[string]*rule)
+
+	for _, code := range []string{"1", "1242", "1246", "7", "44"} {
+		rules[code] = &rule{code: code}
+		trie.add(rules[code], false)
+	}
+
+	var testCases = []struct {
+		digits string
+		code   string
+		length int
+	}{
+		{"12423591234", "1242", 4},
+		{"1242", "1242", 4},
+		{"124", "1", 1},
+		{"12463591234", "1246", 4},
+		{"12125550100", "1", 1},
+		{"447700900123", "44", 2},
+		{"4", "", 0},
+		{"", "", 0},
+		{"7) 9161234567", "7", 1},
+		{"3591234", "", 0},
+	}
+
+	for _, tCase := range testCases {
+		found, length := trie.longest(tCase.digits)
+
+		if tCase.code == "" {
+			require.Empty(t, found, tCase.digits)
+		} else {
+			require.Equal(t, []*rule{rules[tCase.code]}, found, tCase.digits)
+		}
+
+		require.Equal(t, tCase.length, length, tCase.digits)
+	}
+
+	// once 1242 is gone its numbers fall back to 1, the shorter code
+	trie.remove(rules["1242"])
+
+	found, length := trie.longest("12423591234")
+	require.Equal(t, []*rule{rules["1"]}, found)
+	require.Equal(t, 1, length)
+
+	trie.remove(&rule{code: "999"})
+}
+
+func TestCodeTrie_SharedCode(t *testing.T) {
+	var trie codeTrie
+
+	us, ca, bs := &rule{name: "US", code: "1"}, &rule{name: "CA", code: "1"}, &rule{name: "BS", code: "1"}
+
+	trie.add(us, false)
+	trie.add(ca, false)
+	trie.add(bs, true)
+
+	// rules sharing a code are kept in the order they're checked in
+	require.Equal(t, []*rule{bs, us, ca}, trie.rules("1"))
+
+	trie.remove(us)
+	require.Equal(t, []*rule{bs, ca}, trie.rules("1"))
+
+	found, _ := trie.longest("12125550100")
+	require.Equal(t, []*rule{bs, ca}, found)
+
+	require.Empty(t, trie.rules("12"))
+}
@@ -0,0 +1,42 @@
+package service
+
+import "sort"
+
+// dataFields : the fields of a result clients can ask for, mapped to whether the validator is needed to work them out
+var dataFields = map[string]bool{
+	"id":          false,
+	"name":        false,
+	"country":     true,
+	"state":       true,
+	"countryCode": true,
+	"phoneNumber": true,
+	"highlights":  true,
+}
+
+//Fields : the fields of a result clients can ask for, in alphabetical order
+func Fields() []string {
+	var fields []string
+
+	for field := range dataFields {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	return fields
+}
+
+//needsValidation : checks whether any of the fields requested is worked out by the validator
+func needsValidation(fields []string) bool {
+	if len(fields) == 0 {
+		return true
+	}
+
+	for _, field := range fields {
+		if dataFields[field] {
+			return true
+		}
+	}
+
+	return false
+}
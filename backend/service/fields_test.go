@@ -0,0 +1,47 @@
+package service
+
+import (
+	"assessment/model"
+	repoMock "assessment/repository/mock"
+	serviceMock "assessment/service/mock"
+	"context"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestFields(t *testing.T) {
+	require.Equal(t, []string{"country", "countryCode", "highlights", "id", "name", "phoneNumber", "state"}, Fields())
+}
+
+func TestNumberService_QueryWithoutDerivedFields(t *testing.T) {
+	mockRepo := new(repoMock.PhoneNumberRepository)
+	mockRepo.On("LastModified", mock.Anything).Return(time.Time{}, nil)
+	mockValidator := new(serviceMock.NumberValidator)
+
+	mockRepo.On("FetchPhoneNumbers", mock.Anything, model.Filter{}, mock.Anything).
+		Return([]model.Record{{ID: 1, Name: "Yosaf Karrouch ", Phone: "(212) 698054317"}}, nil)
+	mockRepo.On("CountPhoneNumbers", mock.Anything, model.Filter{}).Return(1, nil)
+	mockValidator.On("Validate", mock.Anything).Return("Morocco", "+212", "698054317", true)
+
+	svc := NewNumberService(mockValidator, mockRepo)
+
+	// the validator isn't needed when only stored fields are requested
+	result, err := svc.Query(context.Background(), model.PhoneNumberQuery{
+		Pagination: model.Pagination{Page: 1, Limit: 5},
+		Fields:     []string{"id", "name"},
+	})
+	require.NoError(t, err, "Expected: nil\nGot: %v\n", err)
+	require.Equal(t, []model.Data{{ID: 1, Name: "Yosaf Karrouch"}}, result.Data)
+	require.Equal(t, []string{"id", "name"}, result.Fields)
+	mockValidator.AssertNotCalled(t, "Validate", mock.Anything)
+
+	result, err = svc.Query(context.Background(), model.PhoneNumberQuery{
+		Pagination: model.Pagination{Page: 1, Limit: 5},
+		Fields:     []string{"id", "state"},
+	})
+	require.NoError(t, err, "Expected: nil\nGot: %v\n", err)
+	require.Equal(t, "OK", result.Data[0].State)
+	mockValidator.AssertCalled(t, "Validate", "(212) 698054317")
+}
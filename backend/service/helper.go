@@ -1,36 +1,144 @@
 package service
 
 import (
-	"errors"
+	"assessment/apperror"
+	"assessment/config"
+	"assessment/model"
+	"net/url"
 	"strconv"
+	"strings"
 )
 
-/*validateParams : helps validate the input parameters sent by the client
+// States : the states a phone number can be in, OK when it is valid for its country and NOK otherwise
+var States = []string{"OK", "NOK"}
+
+/*ParseQuery : builds a phone number query from the parameters sent by the client
+The parameters have already been checked against the OpenAPI document of the endpoint, which is built from the states,
+sort keys, fields and page sizes of the service, so only what the document can't express is checked here. It's reported
+the way the document's checks are, as an apperror.ValidationError.
+The page size limits the endpoint was configured with decide the default limit and the largest one allowed.
 Returns:
-	- page <string>
-	- limit <string>
+	- query <model.PhoneNumberQuery>
 	- error <error>
 */
-func validateParams(state, page, limit string) (int, int, error) {
-	// give page a default value of 1 if it is empty
-	if page == "" {
-		page = "1"
+func ParseQuery(values url.Values, pageSize config.PageSize) (model.PhoneNumberQuery, error) {
+	states := listParam(values, "state")
+
+	// asking for every state is the same as not filtering by state at all
+	if len(states) == len(States) {
+		states = nil
+	}
+
+	pagination, err := parsePagination(values, pageSize)
+
+	if err != nil {
+		return model.PhoneNumberQuery{}, err
+	}
+
+	return model.PhoneNumberQuery{
+		Filter: model.Filter{
+			Countries:         listParam(values, "country"),
+			ExcludedCountries: listParam(values, "country!"), // ?country!=morocco is parsed as the key "country!"
+			States:            states,
+			Search:            parseSearch(values.Get("q")),
+		},
+		Sort:       parseSort(listParam(values, "sort")),
+		Fields:     listParam(values, "fields"),
+		Pagination: pagination,
+	}, nil
+}
+
+/*parsePagination : works out which results the client wants from either page and limit or offset and limit
+The OpenAPI document rejects limits above the configured maximum, unless the endpoint is configured to clamp them, in which
+case they're lowered to the maximum here.
+*/
+func parsePagination(values url.Values, pageSize config.PageSize) (model.Pagination, error) {
+	// endpoints which haven't been configured fall back to the default page size
+	pageSize = pageSize.OrDefault()
+
+	page := intParam(values, "page", 1)
+	offset := intParam(values, "offset", -1)
+	limit := intParam(values, "limit", pageSize.Default)
+
+	// a limit of 0 has always meant the default limit
+	if limit == 0 {
+		limit = pageSize.Default
+	}
+
+	if limit > pageSize.Max {
+		limit = pageSize.Max
+	}
+
+	// without an offset, the offset is where the page begins
+	if offset < 0 {
+		return model.Pagination{Page: page, Offset: (page - 1) * limit, Limit: limit}, nil
+	}
+
+	// a document can't say that two parameters exclude each other
+	if values.Get("page") != "" {
+		return model.Pagination{}, invalidParameter("offset", "can't be used with page")
 	}
 
-	// give limit a default value of 5 if it is empty or 0
-	if limit == "" || limit == "0" {
-		limit = "5"
+	// the page is the one the offset falls on
+	return model.Pagination{Page: offset/limit + 1, Offset: offset, Limit: limit}, nil
+}
+
+//intParam : reads a parameter the OpenAPI document checked is a whole number, falling back to the value provided when it isn't sent
+func intParam(values url.Values, key string, fallback int) int {
+	number, err := strconv.Atoi(values.Get(key))
+
+	if err != nil {
+		return fallback
+	}
+
+	return number
+}
+
+//invalidParameter : reports a query parameter which isn't valid, the same way the OpenAPI document's checks do
+func invalidParameter(name, reason string) error {
+	return apperror.ValidationError{Parameters: []apperror.InvalidParameter{{Name: name, In: "query", Reason: reason}}}
+}
+
+/*listParam : collects every value of a parameter which can be repeated or comma separated
+e.g. ?country=cameroon,uganda and ?country=cameroon&country=uganda both return [cameroon uganda]
+*/
+func listParam(values url.Values, key string) []string {
+	var list []string
+
+	for _, value := range values[key] {
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+
+			// skip empty and repeated items
+			if item == "" || contains(list, item) {
+				continue
+			}
+
+			list = append(list, item)
+		}
 	}
 
-	// ensure that the page and limit are digits
-	// ensure that the state values are one of OK, NOK, or empty
-	if !numberRegex.MatchString(limit) || !numberRegex.MatchString(page) || (state != "OK" && state != "NOK" && state != "") {
-		return -1, -1, errors.New("invalid data provided")
+	return list
+}
+
+//contains : checks whether the item is in the list
+func contains(list []string, item string) bool {
+	for _, entry := range list {
+		if entry == item {
+			return true
+		}
 	}
 
-	// convert the page and limit to integers
-	pg, _ := strconv.Atoi(page)
-	lim, _ := strconv.Atoi(limit)
+	return false
+}
+
+//containsFold : like contains, ignoring case as countries are looked up by name
+func containsFold(list []string, item string) bool {
+	for _, entry := range list {
+		if strings.EqualFold(entry, item) {
+			return true
+		}
+	}
 
-	return pg, lim, nil
+	return false
 }
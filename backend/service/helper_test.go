@@ -0,0 +1,57 @@
+package service
+
+import (
+	"assessment/apperror"
+	"assessment/config"
+	"assessment/model"
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"testing"
+)
+
+func TestParsePagination(t *testing.T) {
+	pageSize := config.PageSize{Default: 10, Max: 50}
+
+	var testCases = []struct {
+		raw      string
+		expected model.Pagination
+	}{
+		{"", model.Pagination{Page: 1, Offset: 0, Limit: 10}},
+		{"limit=0", model.Pagination{Page: 1, Offset: 0, Limit: 10}},
+		{"page=3&limit=20", model.Pagination{Page: 3, Offset: 40, Limit: 20}},
+		{"offset=25&limit=20", model.Pagination{Page: 2, Offset: 25, Limit: 20}},
+		{"offset=0", model.Pagination{Page: 1, Offset: 0, Limit: 10}},
+		{"limit=50", model.Pagination{Page: 1, Offset: 0, Limit: 50}},
+	}
+
+	for _, tCase := range testCases {
+		values, _ := url.ParseQuery(tCase.raw)
+
+		pagination, err := parsePagination(values, pageSize)
+		require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+		require.Equal(t, tCase.expected, pagination, tCase.raw)
+	}
+
+	// page and offset exclude each other, which the OpenAPI document can't say
+	values, _ := url.ParseQuery("page=1&offset=5")
+
+	_, err := parsePagination(values, pageSize)
+	require.Equal(t, apperror.ValidationError{Parameters: []apperror.InvalidParameter{
+		{Name: "offset", In: "query", Reason: "can't be used with page"},
+	}}, err)
+
+	// oversize limits only get past the OpenAPI document when the endpoint clamps them, they're lowered to the maximum
+	values, _ = url.ParseQuery("limit=51")
+	pageSize.Clamp = true
+
+	pagination, err := parsePagination(values, pageSize)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Equal(t, 50, pagination.Limit)
+
+	// endpoints without a page size use the default one
+	values, _ = url.ParseQuery("")
+
+	pagination, err = parsePagination(values, config.PageSize{})
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Equal(t, config.DefaultPageSize.Default, pagination.Limit)
+}
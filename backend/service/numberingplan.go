@@ -0,0 +1,168 @@
+package service
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+//go:generate sh -c "cd ../tools/numberingplan && go run . > ../../service/numberingplan.json"
+
+/*numberingPlanJSON : The numbering plan of every country calling code the ITU assigns, exported from libphonenumber
+Territories sharing a calling code are listed with the main country of the code first. Every territory has the lengths
+its national numbers can be, the leading digits telling it apart from the others sharing its code when there are any,
+and a pattern for each type of number it has (fixed line, mobile, toll free...).
+*/
+//go:embed numberingplan.json
+var numberingPlanJSON []byte
+
+//territory : the numbering plan of a country, or of a calling code which doesn't belong to one, compiled once
+type territory struct {
+	id            string
+	name          string
+	code          string
+	leadingDigits *regexp.Regexp // nil unless other territories share the code
+	lengths       []int
+	types         []numberType
+}
+
+//numberType : the national numbers of one type, e.g. mobile
+type numberType struct {
+	name    string
+	pattern *regexp.Regexp
+	lengths []int
+}
+
+var (
+	planOnce    sync.Once
+	territories []*territory
+)
+
+/*numberingPlan : The territories of the embedded numbering plan, compiled the first time they're needed
+Every validator shares them, they never change once compiled.
+*/
+func numberingPlan() []*territory {
+	planOnce.Do(func() {
+		var err error
+
+		if territories, err = parseNumberingPlan(numberingPlanJSON); err != nil {
+			panic(fmt.Sprintf("the embedded numbering plan is broken: %v", err))
+		}
+	})
+
+	return territories
+}
+
+func parseNumberingPlan(data []byte) ([]*territory, error) {
+	var plan struct {
+		Territories []struct {
+			ID            string `json:"id"`
+			Name          string `json:"name"`
+			Code          string `json:"code"`
+			LeadingDigits string `json:"leadingDigits"`
+			Lengths       []int  `json:"lengths"`
+			Types         map[string]struct {
+				Pattern string `json:"pattern"`
+				Lengths []int  `json:"lengths"`
+			} `json:"types"`
+		} `json:"territories"`
+	}
+
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, err
+	}
+
+	result := make([]*territory, 0, len(plan.Territories))
+
+	for _, t := range plan.Territories {
+		if !isCode(t.Code) {
+			return nil, fmt.Errorf("%s: the calling code should be made of digits, got %q", t.ID, t.Code)
+		}
+
+		compiled := &territory{id: t.ID, name: t.Name, code: t.Code, lengths: t.Lengths}
+
+		if t.LeadingDigits != "" {
+			leadingDigits, err := regexp.Compile(`^(?:` + t.LeadingDigits + `)`)
+
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", t.ID, err)
+			}
+
+			compiled.leadingDigits = leadingDigits
+		}
+
+		// the types are checked in a fixed order, so a number of two types is always reported as the same one
+		for _, name := range numberTypes {
+			desc, ok := t.Types[name]
+
+			if !ok {
+				continue
+			}
+
+			pattern, err := regexp.Compile(`^(?:` + desc.Pattern + `)$`)
+
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", t.ID, name, err)
+			}
+
+			lengths := desc.Lengths
+
+			// a type without lengths of its own has the lengths of the territory
+			if len(lengths) == 0 {
+				lengths = t.Lengths
+			}
+
+			compiled.types = append(compiled.types, numberType{name: name, pattern: pattern, lengths: lengths})
+		}
+
+		result = append(result, compiled)
+	}
+
+	return result, nil
+}
+
+// numberTypes : the types of numbers in the numbering plan, the most common first
+var numberTypes = []string{
+	"fixedLine",
+	"mobile",
+	"tollFree",
+	"premiumRate",
+	"sharedCost",
+	"personalNumber",
+	"voip",
+	"pager",
+	"uan",
+	"voicemail",
+}
+
+//leads : checks whether the national number starts with the digits telling the territory apart from others sharing its code
+func (t *territory) leads(number string) bool {
+	return t.leadingDigits != nil && t.leadingDigits.MatchString(number)
+}
+
+//numberType : the type of the national number, empty when it isn't a valid number of the territory
+func (t *territory) numberType(number string) string {
+	if !hasLength(t.lengths, len(number)) {
+		return ""
+	}
+
+	for _, numberType := range t.types {
+		if hasLength(numberType.lengths, len(number)) && numberType.pattern.MatchString(number) {
+			return numberType.name
+		}
+	}
+
+	return ""
+}
+
+func hasLength(lengths []int, length int) bool {
+	for _, l := range lengths {
+		if l == length {
+			return true
+		}
+	}
+
+	return false
+}
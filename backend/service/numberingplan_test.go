@@ -0,0 +1,85 @@
+package service
+
+import (
+	"assessment/model"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestNumberingPlan(t *testing.T) {
+	plan := numberingPlan()
+
+	codes := make(map[string]bool)
+	names := make(map[string]bool)
+
+	for _, territory := range plan {
+		codes[territory.code] = true
+
+		require.NotEmpty(t, territory.name, territory.id)
+		require.NotEmpty(t, territory.lengths, territory.id)
+		require.NotEmpty(t, territory.types, territory.id)
+
+		// countries are looked up by name, so no two can share one
+		require.False(t, names[strings.ToLower(territory.name)], territory.name)
+		names[strings.ToLower(territory.name)] = true
+	}
+
+	// every calling code in use, including the ones which don't belong to a country like +800
+	require.Greater(t, len(codes), 200)
+	require.True(t, codes["800"])
+	require.True(t, codes["1"])
+
+	_, err := parseNumberingPlan([]byte(`{"territories": [{"id": "XX", "name": "Nowhere", "code": "+99"}]}`))
+	require.Error(t, err)
+
+	_, err = parseNumberingPlan([]byte(`{"territories": [{"id": "XX", "name": "Nowhere", "code": "99", "types": {"mobile": {"pattern": "(9"}}}]}`))
+	require.Error(t, err)
+}
+
+func TestValidator_NumberingPlan(t *testing.T) {
+	v := NewValidator()
+
+	var testCases = []struct {
+		phone string
+		data  model.Data
+	}{
+		{"(234) 8021234567", model.Data{Country: "Nigeria", CountryCode: "+234", PhoneNumber: "8021234567", State: "OK"}},
+		{"(234) 123", model.Data{Country: "Nigeria", CountryCode: "+234", PhoneNumber: "123", State: "NOK"}},
+		{"(49) 15123456789", model.Data{Country: "Germany", CountryCode: "+49", PhoneNumber: "15123456789", State: "OK"}},
+		// national numbers are written without their trunk prefix
+		{"(33) 612345678", model.Data{Country: "France", CountryCode: "+33", PhoneNumber: "612345678", State: "OK"}},
+		{"(33) 0612345678", model.Data{Country: "France", CountryCode: "+33", PhoneNumber: "0612345678", State: "NOK"}},
+		// countries sharing a code are told apart by their leading digits, or by which one the number is valid in
+		{"(1) 2015550123", model.Data{Country: "United States", CountryCode: "+1", PhoneNumber: "2015550123", State: "OK"}},
+		{"(1) 5062345678", model.Data{Country: "Canada", CountryCode: "+1", PhoneNumber: "5062345678", State: "OK"}},
+		{"(1) 2423591234", model.Data{Country: "Bahamas", CountryCode: "+1", PhoneNumber: "2423591234", State: "OK"}},
+		{"(1242) 3591234", model.Data{Country: "Bahamas", CountryCode: "+1", PhoneNumber: "2423591234", State: "OK"}},
+		{"(1) 2421234", model.Data{Country: "Bahamas", CountryCode: "+1", PhoneNumber: "2421234", State: "NOK"}},
+		{"(44) 7400123456", model.Data{Country: "United Kingdom", CountryCode: "+44", PhoneNumber: "7400123456", State: "OK"}},
+		{"(44) 7781123456", model.Data{Country: "Guernsey", CountryCode: "+44", PhoneNumber: "7781123456", State: "OK"}},
+		{"(44) 12", model.Data{Country: "United Kingdom", CountryCode: "+44", PhoneNumber: "12", State: "NOK"}},
+		{"(7) 9123456789", model.Data{Country: "Russia", CountryCode: "+7", PhoneNumber: "9123456789", State: "OK"}},
+		{"(7) 7123456789", model.Data{Country: "Kazakhstan", CountryCode: "+7", PhoneNumber: "7123456789", State: "OK"}},
+		{"(39) 0669812345", model.Data{Country: "Vatican City", CountryCode: "+39", PhoneNumber: "0669812345", State: "OK"}},
+		{"(800) 12345678", model.Data{Country: "International Freephone Service", CountryCode: "+800", PhoneNumber: "12345678", State: "OK"}},
+		// Western Sahara shares Morocco's code, whose numbers are still checked with the preset rule
+		{"(212) 528812345", model.Data{Country: "Morocco", CountryCode: "+212", PhoneNumber: "528812345", State: "OK"}},
+		{"(999) 12345", model.Data{}},
+	}
+
+	for _, tCase := range testCases {
+		country, code, number, valid := v.Validate(tCase.phone)
+		require.Equal(t, tCase.data.Country, country, tCase.phone)
+		require.Equal(t, tCase.data.CountryCode, code, tCase.phone)
+		require.Equal(t, tCase.data.PhoneNumber, number, tCase.phone)
+		require.Equal(t, tCase.data.State == "OK", valid, tCase.phone)
+	}
+
+	// every country in the plan can be filtered by
+	for _, territory := range numberingPlan() {
+		code, err := v.GetCodeFromCountry(territory.name)
+		require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+		require.Equal(t, territory.code, code, territory.name)
+	}
+}
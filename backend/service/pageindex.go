@@ -0,0 +1,180 @@
+package service
+
+import (
+	"assessment/model"
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+)
+
+/*pageIndex : remembers where the pages of a filtered scan begin
+Numbers filtered by state are non-contiguous in the database, so the only way to find where page n starts is to scan
+every page before it. The index records the last record on every page it has seen, so a later request for
+that page (or any page after it) can resume the scan from the closest known boundary instead of from the first record.
+Every search is a query of its own, so the index only keeps the queries used most recently and a bounded number of pages
+for each. It's emptied whenever the data changes, as the boundaries don't hold for the new data.
+*/
+type pageIndex struct {
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List // most recently used first
+	version    time.Time  // when the data the boundaries were recorded on last changed
+	generation uint64     // moves on whenever the index is emptied, so scans which started before can't record anything
+}
+
+//pageIndexEntry : the pages found for a query
+type pageIndexEntry struct {
+	key        string
+	boundaries []model.Record // the last record of every page, in order
+	count      int            // how many records matched, once a scan has reached the end
+	finished   bool
+}
+
+const (
+	// pageIndexSize : the most queries whose pages are remembered
+	pageIndexSize = 1000
+
+	// pageIndexBoundaries : the most pages remembered for a query, later pages are scanned for from the last one known
+	pageIndexBoundaries = 10000
+)
+
+func newPageIndex() *pageIndex {
+	return &pageIndex{
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+/*sync : records when the data last changed, forgetting every boundary when it has changed since the last call
+Returns the generation scans reading this version of the data have to record their pages with.
+*/
+func (idx *pageIndex) sync(version time.Time) uint64 {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if !version.Equal(idx.version) {
+		idx.version = version
+		idx.reset()
+	}
+
+	return idx.generation
+}
+
+//current : the generation pages found from now on have to be recorded with
+func (idx *pageIndex) current() uint64 {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	return idx.generation
+}
+
+func (idx *pageIndex) reset() {
+	idx.generation++
+	idx.entries = make(map[string]*list.Element)
+	idx.order.Init()
+}
+
+//entry : the entry of the query, marked as used most recently, nil when it isn't there and create isn't set
+func (idx *pageIndex) entry(key string, create bool) *pageIndexEntry {
+	if element, ok := idx.entries[key]; ok {
+		idx.order.MoveToFront(element)
+		return element.Value.(*pageIndexEntry)
+	}
+
+	if !create {
+		return nil
+	}
+
+	// the query used least recently makes room
+	if idx.order.Len() >= pageIndexSize {
+		oldest := idx.order.Back()
+		idx.order.Remove(oldest)
+		delete(idx.entries, oldest.Value.(*pageIndexEntry).key)
+	}
+
+	entry := &pageIndexEntry{key: key}
+	idx.entries[key] = idx.order.PushFront(entry)
+
+	return entry
+}
+
+//pageIndexKey : page boundaries only hold for the exact filter, order and page size they were recorded with
+func pageIndexKey(query model.PhoneNumberQuery) string {
+	filter := query.Filter
+
+	return fmt.Sprintf("%s|%s|%v|%+v|%v|%d",
+		sortedFold(filter.Countries), sortedFold(filter.ExcludedCountries), filter.States, filter.Search, query.Sort, query.Pagination.Limit)
+}
+
+/*closest : finds the closest page to the requested one whose starting point is known
+Returns:
+	- page  <int>
+	- after <*model.Record> the record the scan for that page should start after, nil when it starts from the beginning
+*/
+func (idx *pageIndex) closest(key string, page int) (int, *model.Record) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	var known []model.Record
+
+	if entry := idx.entry(key, false); entry != nil {
+		known = entry.boundaries
+	}
+
+	// page 1 always starts at the beginning, page n starts after the last record of page n-1
+	if page > len(known)+1 {
+		page = len(known) + 1
+	}
+
+	if page == 1 {
+		return 1, nil
+	}
+
+	last := known[page-2]
+
+	return page, &last
+}
+
+//record : stores the last record on the given page, found by a scan of the given generation
+func (idx *pageIndex) record(key string, generation uint64, page int, last model.Record) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	// the data changed while the scan was running
+	if generation != idx.generation {
+		return
+	}
+
+	entry := idx.entry(key, true)
+
+	// boundaries are only ever discovered in order, so anything other than the next page is already known
+	if page == len(entry.boundaries)+1 && page <= pageIndexBoundaries {
+		entry.boundaries = append(entry.boundaries, last)
+	}
+}
+
+//finish : stores how many records matched, which is only known once a scan has reached the end of the records
+func (idx *pageIndex) finish(key string, generation uint64, count int) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if generation != idx.generation {
+		return
+	}
+
+	entry := idx.entry(key, true)
+	entry.count, entry.finished = count, true
+}
+
+//count : how many records a previous scan found, false if no scan has reached the end yet
+func (idx *pageIndex) count(key string) (int, bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if entry := idx.entry(key, false); entry != nil && entry.finished {
+		return entry.count, true
+	}
+
+	return 0, false
+}
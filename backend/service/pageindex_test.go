@@ -0,0 +1,118 @@
+package service
+
+import (
+	"assessment/model"
+	repoMock "assessment/repository/mock"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestPageIndex_Sync(t *testing.T) {
+	idx := newPageIndex()
+	before := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	generation := idx.sync(before)
+	idx.record("key", generation, 1, model.Record{ID: 5})
+	idx.finish("key", generation, 7)
+
+	// the data hasn't changed, so the pages still hold
+	require.Equal(t, generation, idx.sync(before))
+
+	page, after := idx.closest("key", 2)
+	require.Equal(t, 2, page)
+	require.Equal(t, int64(5), after.ID)
+
+	// the data changes, every page is forgotten
+	changed := idx.sync(before.Add(time.Minute))
+	require.NotEqual(t, generation, changed)
+
+	page, after = idx.closest("key", 2)
+	require.Equal(t, 1, page)
+	require.Nil(t, after)
+
+	_, ok := idx.count("key")
+	require.False(t, ok)
+
+	// a scan of the old data finishing late doesn't put its pages back
+	idx.record("key", generation, 1, model.Record{ID: 5})
+	idx.finish("key", generation, 7)
+
+	page, _ = idx.closest("key", 2)
+	require.Equal(t, 1, page)
+
+	_, ok = idx.count("key")
+	require.False(t, ok)
+}
+
+func TestPageIndex_Bounded(t *testing.T) {
+	idx := newPageIndex()
+	generation := idx.current()
+
+	// every query past the size pushes out the one used least recently
+	for i := 0; i < pageIndexSize+10; i++ {
+		idx.record(fmt.Sprint(i), generation, 1, model.Record{ID: int64(i)})
+
+		// the first query keeps being used, so it's never the least recent
+		idx.closest("0", 2)
+	}
+
+	require.Equal(t, pageIndexSize, idx.order.Len())
+	require.Len(t, idx.entries, pageIndexSize)
+
+	page, _ := idx.closest("0", 2)
+	require.Equal(t, 2, page)
+
+	page, _ = idx.closest("1", 2)
+	require.Equal(t, 1, page)
+
+	page, _ = idx.closest(fmt.Sprint(pageIndexSize+9), 2)
+	require.Equal(t, 2, page)
+
+	// a query only remembers so many pages, later ones are scanned for from the last page it knows
+	for page := 1; page <= pageIndexBoundaries+5; page++ {
+		idx.record("long", generation, page, model.Record{ID: int64(page)})
+	}
+
+	page, after := idx.closest("long", pageIndexBoundaries+5)
+	require.Equal(t, pageIndexBoundaries+1, page)
+	require.Equal(t, int64(pageIndexBoundaries), after.ID)
+}
+
+func TestNumberService_PageIndexResetWithoutResultCache(t *testing.T) {
+	mockRepo := new(repoMock.PhoneNumberRepository)
+
+	before := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	mockRepo.On("LastModified", mock.Anything).Return(before, nil).Once()
+	mockRepo.On("LastModified", mock.Anything).Return(before.Add(time.Hour), nil)
+	mockRepo.On("ScanPhoneNumbers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(scanTable("(237) 100000001", "(237) 100000002", "(237) 100000003")).Return(nil)
+
+	svc := NewNumberService(NewValidator(), mockRepo, WithResultCache(0))
+
+	query := model.PhoneNumberQuery{
+		Filter:     model.Filter{States: []string{"NOK"}},
+		Pagination: model.Pagination{Page: 1, Limit: 2},
+	}
+
+	_, err := svc.Query(context.Background(), query)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+
+	page, _ := svc.pages.closest(pageIndexKey(query), 2)
+	require.Equal(t, 2, page)
+
+	// the data changed since, so page 2 is scanned for from the beginning rather than after the end of page 1
+	query.Pagination = model.Pagination{Page: 2, Offset: 2, Limit: 2}
+
+	result, err := svc.Query(context.Background(), query)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Len(t, result.Data, 1)
+
+	scan := mockRepo.Calls[len(mockRepo.Calls)-1]
+	require.Equal(t, "ScanPhoneNumbers", scan.Method)
+	require.Nil(t, scan.Arguments.Get(2).(model.Cursor).After)
+}
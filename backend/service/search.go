@@ -0,0 +1,128 @@
+package service
+
+import (
+	"assessment/model"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	numberSearchRegex = regexp.MustCompile(`^\+?[\d\s()-]+\*?$`)
+	nonDigitRegex     = regexp.MustCompile(`\D`)
+)
+
+// SearchPattern : searches have to contain a letter or a digit, anything else is punctuation there's nothing to look for in
+const SearchPattern = `[\p{L}\p{N}]`
+
+const (
+	highlightStart = "<mark>"
+	highlightEnd   = "</mark>"
+)
+
+/*parseSearch : works out what the client is searching for
+Searches made up of digits look through the national numbers, e.g. 69715 finds every number containing 69715 while 69715*
+only finds numbers starting with it.
+Anything else looks through the customers' names, where every word searched for has to start one of the words in the name,
+e.g. "walid" finds "Walid Karim" and "wal kar" finds "Walid Karim" as well.
+The OpenAPI document only lets searches matching SearchPattern through, a search with nothing to look for searches nothing.
+*/
+func parseSearch(q string) model.Search {
+	q = strings.TrimSpace(q)
+
+	if numberSearchRegex.MatchString(q) {
+		search := model.Search{
+			Number: nonDigitRegex.ReplaceAllString(q, ""),
+			Prefix: strings.HasSuffix(q, "*"),
+		}
+
+		if search.Number == "" {
+			return model.Search{}
+		}
+
+		return search
+	}
+
+	return model.Search{Terms: nameTerms(q)}
+}
+
+//nameTerms : splits text into lower case words the same way the full text index does
+func nameTerms(text string) []string {
+	var terms []string
+
+	for _, word := range strings.FieldsFunc(text, isSeparator) {
+		terms = append(terms, strings.ToLower(word))
+	}
+
+	return terms
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
+
+/*highlight : marks the parts of a result which matched the search
+Returns nil if nothing is being searched for.
+*/
+func highlight(search model.Search, name, number string) *model.Highlights {
+	switch {
+	case search.Number != "":
+		index := strings.Index(number, search.Number)
+
+		if index < 0 || (search.Prefix && index != 0) {
+			return nil
+		}
+
+		end := index + len(search.Number)
+
+		return &model.Highlights{
+			PhoneNumber: number[:index] + highlightStart + number[index:end] + highlightEnd + number[end:],
+		}
+
+	case len(search.Terms) > 0:
+		return &model.Highlights{Name: highlightWords(name, search.Terms)}
+	}
+
+	return nil
+}
+
+//highlightWords : wraps every word in the text which starts with one of the terms
+func highlightWords(text string, terms []string) string {
+	var (
+		builder strings.Builder
+		word    strings.Builder
+	)
+
+	flush := func() {
+		if word.Len() == 0 {
+			return
+		}
+
+		lower := strings.ToLower(word.String())
+
+		for _, term := range terms {
+			if strings.HasPrefix(lower, term) {
+				builder.WriteString(highlightStart + word.String() + highlightEnd)
+				word.Reset()
+				return
+			}
+		}
+
+		builder.WriteString(word.String())
+		word.Reset()
+	}
+
+	for _, r := range text {
+		if isSeparator(r) {
+			flush()
+			builder.WriteRune(r)
+			continue
+		}
+
+		word.WriteRune(r)
+	}
+
+	flush()
+
+	return builder.String()
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"assessment/model"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+)
+
+func TestParseSearch(t *testing.T) {
+	var testCases = []struct {
+		q        string
+		expected model.Search
+	}{
+		{"", model.Search{}},
+		{"69715", model.Search{Number: "69715"}},
+		{" 697 15* ", model.Search{Number: "69715", Prefix: true}},
+		{"Walid", model.Search{Terms: []string{"walid"}}},
+		{"walla's SINGZ", model.Search{Terms: []string{"walla", "s", "singz"}}},
+		{"shop23", model.Search{Terms: []string{"shop23"}}},
+	}
+
+	for _, tCase := range testCases {
+		require.Equal(t, tCase.expected, parseSearch(tCase.q))
+	}
+
+	// punctuation only searches are rejected by the OpenAPI document, there's nothing to look for in them
+	require.Equal(t, model.Search{}, parseSearch("()"))
+	require.Equal(t, model.Search{}, parseSearch("***"))
+	require.False(t, regexp.MustCompile(SearchPattern).MatchString("()"))
+	require.True(t, regexp.MustCompile(SearchPattern).MatchString("é"))
+}
+
+func TestHighlight(t *testing.T) {
+	require.Nil(t, highlight(model.Search{}, "Walid Karim", "697151594"))
+
+	require.Equal(t,
+		&model.Highlights{PhoneNumber: "6<mark>97151</mark>594"},
+		highlight(model.Search{Number: "97151"}, "Walid Karim", "697151594"),
+	)
+
+	// a prefix search only matches the start of the number
+	require.Nil(t, highlight(model.Search{Number: "97151", Prefix: true}, "Walid Karim", "697151594"))
+
+	require.Equal(t,
+		&model.Highlights{Name: "<mark>Walid</mark> Ka-<mark>Karim</mark>"},
+		highlight(model.Search{Terms: []string{"wal", "kar"}}, "Walid Ka-Karim", ""),
+	)
+}
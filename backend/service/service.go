@@ -4,91 +4,357 @@ import (
 	"assessment/apperror"
 	"assessment/model"
 	"assessment/repository"
+	"context"
+	"errors"
 	"log"
-	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
-var numberRegex = regexp.MustCompile(`^\d+$`)
-
 type NumberService struct {
 	validator  NumberValidator
 	repository repository.PhoneNumberRepository
+	pages      *pageIndex
+	flights    *queryGroup
+	results    *resultCache    // nil when results aren't cached
+	pool       *validationPool // validates the records of scans which read every record
+	timeout    time.Duration   // how long a single repository query may take, 0 for as long as it needs
+}
+
+//Option : Changes how a NumberService behaves, for when the defaults don't fit
+type Option func(s *NumberService)
+
+/*WithResultCache : Keeps the result of every query for the given time, so identical queries within it skip the repository
+Cached results are dropped as soon as the repository reports that the data changed. A TTL of 0 or less caches nothing.
+*/
+func WithResultCache(ttl time.Duration) Option {
+	return func(s *NumberService) {
+		if ttl > 0 {
+			s.results = newResultCache(ttl)
+		}
+	}
+}
+
+/*WithQueryTimeout : Gives up on every repository query which takes longer than the given time
+Queries which time out fail with apperror.Timeout. A timeout of 0 or less lets queries run until the request is done.
+*/
+func WithQueryTimeout(timeout time.Duration) Option {
+	return func(s *NumberService) {
+		s.timeout = timeout
+	}
+}
+
+/*WithValidationWorkers : Validates the records read by full scans on the given number of goroutines
+Sorting by a computed field validates every matching record, which is what it mostly spends its time on. Pages filtered
+by state or country stop reading as soon as they're full, so they validate records as they're read instead.
+0 or less uses one worker per CPU, 1 validates records one after the other as they're read.
+*/
+func WithValidationWorkers(workers int) Option {
+	return func(s *NumberService) {
+		s.pool = newValidationPool(workers)
+	}
 }
 
 /*NewNumberService : This starts a new service which handles the business logic of returning
 phone numbers with the specified criteria
 */
-func NewNumberService(validator NumberValidator, repository repository.PhoneNumberRepository) *NumberService {
-	return &NumberService{
+func NewNumberService(validator NumberValidator, repository repository.PhoneNumberRepository, options ...Option) *NumberService {
+	s := &NumberService{
 		validator:  validator,
 		repository: repository,
+		pages:      newPageIndex(),
+		flights:    newQueryGroup(),
+		pool:       newValidationPool(0),
 	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
 }
 
-/*FetchPhoneNumbers : Fetches all the phone numbers in the database
-Returns a paginated list of all phone numbers in the database.
+/*lastModified : The last time the phone numbers changed, the zero time when it isn't known
+The modification time only decides what's cached and the caching headers of a response, so failing to read it isn't an
+error. It gives up after the query timeout like any other query.
 */
-func (s *NumberService) FetchPhoneNumbers(page, limit string) (model.Result, error) {
-	off, lim, err := validateParams("", page, limit) // validates the input parameters
+func (s *NumberService) lastModified(ctx context.Context) time.Time {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	lastModified, err := s.repository.LastModified(ctx)
 
-	// return an error if an unsupported parameter is received
 	if err != nil {
-		return model.Result{}, apperror.BadRequest
+		log.Printf("Error reading when the phone numbers were last modified: %v", err)
+		return time.Time{}
 	}
 
-	// calculate the offset to be used for fetching subsequent
+	return lastModified
+}
+
+/*Health : Whether the repository can be reached, along with the state of its connections
+Repositories which can't tell are assumed to be up. The check gives up after the query timeout like any other query.
+*/
+func (s *NumberService) Health(ctx context.Context) model.Health {
+	checker, ok := s.repository.(repository.HealthChecker)
+
+	if !ok {
+		return model.Health{Status: "up"}
+	}
+
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return checker.Health(ctx)
+}
+
+/*Query : Fetches the phone numbers matching the query provided
+Returns a paginated list of the phone numbers which satisfy every filter in the query, along with when they last changed.
+The repository stops being read as soon as the context is done, e.g. when the client disconnects.
+*/
+func (s *NumberService) Query(ctx context.Context, query model.PhoneNumberQuery) (model.Result, error) {
+	var err error
+
+	// get the codes for the specified countries so the database only has to be read for numbers with those codes
+	if query.Filter.Codes, err = s.codesFor(query.Filter.Countries); err != nil {
+		return model.Result{}, err
+	}
+
+	// excluded countries can share their code with others (Canada with the United States), so excluding their code
+	// would drop numbers which are meant to stay, they're only checked to exist
+	if _, err = s.codesFor(query.Filter.ExcludedCountries); err != nil {
+		return model.Result{}, err
+	}
+
+	key := queryKey(query)
+
+	// everything worked out from the data is dropped as soon as it changes, whether results are cached or not
+	lastModified := s.lastModified(ctx)
+
+	s.pages.sync(lastModified)
+
+	if s.results == nil {
+		return s.do(ctx, key, query, lastModified, nil)
+	}
+
+	generation := s.results.sync(lastModified)
+
+	if result, ok := s.results.get(key); ok {
+		return result, nil
+	}
+
+	return s.do(ctx, key, query, lastModified, func(result model.Result) { s.results.put(key, result, generation) })
+}
+
+/*do : Runs the query along with identical queries running at the same time, passing a successful result to done
+The result is stamped with the version of the data it was read from, so a cached result keeps its own.
+*/
+func (s *NumberService) do(
+	ctx context.Context,
+	key string,
+	query model.PhoneNumberQuery,
+	lastModified time.Time,
+	done func(model.Result),
+) (model.Result, error) {
+	result, err := s.flights.do(ctx, key, func(ctx context.Context) (model.Result, error) {
+		result, err := s.run(ctx, query)
+		result.LastModified = lastModified
+
+		if err == nil && done != nil {
+			done(result)
+		}
+
+		return result, err
+	})
+
+	// the caller went away or ran out of time before the query finished
+	if err != nil && ctx.Err() != nil {
+		return model.Result{}, queryError(ctx, err)
+	}
+
+	return result, err
+}
+
+//run : Runs the query on the repository, picking the way of reading it which suits its filters and order
+func (s *NumberService) run(ctx context.Context, query model.PhoneNumberQuery) (model.Result, error) {
+	// the country and state of a number aren't stored in the database, so sorting by them has to happen here
+	if isComputedSort(query.Sort) {
+		return s.sortInMemory(ctx, query)
+	}
+
+	// neither is the state of a number nor the country it's validated as, so they have to be filtered here
+	if isComputedFilter(query.Filter) {
+		return s.filterByState(ctx, query)
+	}
+
+	return s.fetchPage(ctx, query)
+}
+
+//fetch : Reads a page of records from the repository, giving up once the query timeout has passed
+func (s *NumberService) fetch(ctx context.Context, filter model.Filter, cursor model.Cursor) ([]model.Record, error) {
+	// there's no point querying the repository for a client which is already gone
+	if err := ctx.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	records, err := s.repository.FetchPhoneNumbers(ctx, filter, cursor)
+
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	return records, nil
+}
+
+/*scan : Hands the records in the repository matching the filter to yield as they're read, until yield returns false
+The whole scan is a single query, so it gives up once the query timeout has passed since it started.
+*/
+func (s *NumberService) scan(ctx context.Context, filter model.Filter, cursor model.Cursor, yield func(model.Record) bool) error {
+	if err := ctx.Err(); err != nil {
+		return queryError(ctx, err)
+	}
+
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	if err := s.repository.ScanPhoneNumbers(ctx, filter, cursor, yield); err != nil {
+		return queryError(ctx, err)
+	}
+
+	return nil
+}
+
+/*validate : Scans the records matching the filter, handing every record to emit along with its data until emit returns false
+The records are validated on the service's pool of workers, but emit is still called from one goroutine at a time and in
+the order the records were read. The pool reads ahead by whole batches, so it's meant for scans which read every record
+rather than ones which stop after a page.
+*/
+func (s *NumberService) validate(
+	ctx context.Context,
+	filter model.Filter,
+	cursor model.Cursor,
+	emit func(model.Record, model.Data) bool,
+) error {
+	err := s.pool.run(
+		func(yield func(model.Record) bool) error { return s.scan(ctx, filter, cursor, yield) },
+		func(record model.Record) model.Data { return s.toData(record, filter.Search) },
+		emit,
+	)
+
+	// the scan reports its own errors as they should reach the client, a panic is a bug which only the logs should show
+	var panicked *panicError
+
+	if errors.As(err, &panicked) {
+		log.Println(panicked)
+		return apperror.ServerError
+	}
+
+	return err
+}
+
+//count : Counts the records in the repository matching the filter, giving up once the query timeout has passed
+func (s *NumberService) count(ctx context.Context, filter model.Filter) (int, error) {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	count, err := s.repository.CountPhoneNumbers(ctx, filter)
+
+	if err != nil {
+		return 0, queryError(ctx, err)
+	}
+
+	return count, nil
+}
+
+//queryContext : The context a single repository query runs with, bounded by the query timeout when there is one
+func (s *NumberService) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, s.timeout)
+}
+
+/*queryError : Converts an error from reading the repository into the error returned to the client
+Queries which ran out of time are reported with a 504, queries of clients which went away with a 499 nobody will read,
+and anything else is logged and reported as a server error since it's typically a db outage or unavailability.
+*/
+func queryError(ctx context.Context, err error) error {
+	// not every driver reports why a query was interrupted, the context always knows
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		err = ctxErr
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Printf("Query timed out: %v", err)
+		return apperror.Timeout
+	case errors.Is(err, context.Canceled):
+		return apperror.Canceled
+	}
+
+	log.Println(err)
+
+	return apperror.ServerError
+}
+
+//fetchPage : Fetches a page of phone numbers which can be filtered entirely by the repository
+func (s *NumberService) fetchPage(ctx context.Context, query model.PhoneNumberQuery) (model.Result, error) {
+	// the offset has already been worked out from the page if the client didn't send one
 	// e.g page 2 with a limit of 5 per page will begin search from position 5 in the database
-	off = lim*off - lim
+	off, lim := query.Pagination.Offset, query.Pagination.Limit
 
 	// fetch the requested phone numbers from the database using value of specified limit + 1.
 	// the reason for this is to simulate a lookahead for ensuring that there's still more data even after the requested limit is satisfied
-	result, err := s.repository.FetchPaginatedPhoneNumbers(off, lim+1)
+	records, err := s.fetch(ctx, query.Filter, model.Cursor{Sort: query.Sort, Offset: off, Limit: lim + 1})
 
 	// ensure that no error was returned
-	// this would typically be a serious error such as db outage or unavailability
+	// this would typically be a serious error such as db outage or unavailability, or the query running out of time
 	if err != nil {
-		log.Println(err)                            // log the error
-		return model.Result{}, apperror.ServerError // return an internal server error
+		return model.Result{}, err
+	}
+
+	// count every matching number so the client can be told which page is the last
+	count, err := s.count(ctx, query.Filter)
+
+	if err != nil {
+		return model.Result{}, err
 	}
 
 	// declare variable for holding result metadata
-	var meta model.Meta
+	meta := newMeta(query.Pagination)
+
+	meta.Count, meta.LastPage = count, lastPage(count, lim)
 
 	// check whether the returned data has an extra data that serves as lookahead.
 	// existence of this extra data informs that there is still more data to be read from the db
-	if len(result) == lim+1 {
-		result = result[:lim]
+	if len(records) == lim+1 {
+		records = records[:lim]
 		meta.Next = true
 	}
 
-	// if the offset is greater than the limit then we definitely aren't on the first page
-	if off >= lim {
+	// anything after the first result means we definitely aren't on the first page
+	if off > 0 {
 		meta.Prev = true
-
 	}
 
-	meta.CurrentPage = page
-
 	var data []model.Data
 
-	// load the data from the db into the result object
-	for _, number := range result {
-		country, code, number, valid := s.validator.Validate(number)
-
-		state := "OK"
+	// running every number through the validator is only worth it if a field worked out by the validator was requested
+	validate := needsValidation(query.Fields)
 
-		// if the state of the phone number is not valid then set it as Not Okay (NOK)
-		if !valid {
-			state = "NOK"
+	// load the data from the db into the result object
+	for _, record := range records {
+		if !validate {
+			data = append(data, model.Data{ID: record.ID, Name: strings.TrimSpace(record.Name)})
+			continue
 		}
 
-		data = append(data, model.Data{
-			Country:     country,
-			CountryCode: code,
-			PhoneNumber: number,
-			State:       state,
-		})
+		data = append(data, s.toData(record, query.Filter.Search))
 	}
 
 	// if an empty result set was returned them there's no next or previous.
@@ -101,371 +367,220 @@ func (s *NumberService) FetchPhoneNumbers(page, limit string) (model.Result, err
 
 	// prepare the final result object
 	finalResult := model.Result{
-		Data: data,
-		Meta: meta,
+		Data:   data,
+		Meta:   meta,
+		Fields: query.Fields,
 	}
 
 	return finalResult, nil
 }
 
-//FilterByState : Filter phone numbers by the validity specified by the client
-func (s *NumberService) FilterByState(state, page, limit string) (model.Result, error) {
-	pg, lim, err := validateParams(state, page, limit) // validate the input
+/*filterByState : Filter phone numbers by the validity specified by the client, on top of the repository filters
+Numbers of a given state are non-contiguous in the database, e.g:
+	1. OK
+	2. NOK
+	3. NOK
+	4. OK
+	5. NOK
+
+Fetching page 1 of NOK numbers with a limit of 2 ends on number 3, so page 2 has to start scanning after number 3.
+Since the start of a page can't be calculated, the records are scanned in a single pass in the requested order
+and the record ending every page found along the way is saved in the page index.
+Later requests resume from the closest known page, so a page that has been reached before costs O(limit) to fetch.
+*/
+func (s *NumberService) filterByState(ctx context.Context, query model.PhoneNumberQuery) (model.Result, error) {
+	off, lim := query.Pagination.Offset, query.Pagination.Limit
 
-	// return an error if unacceptable input is returned
-	if err != nil {
-		return model.Result{}, apperror.BadRequest
-	}
+	key, generation := pageIndexKey(query), s.pages.current()
 
-	// The offset here is 0 instead of being calculated (further details below)
-	offset := 0
+	// start from the closest page to the one the offset falls on whose starting point is known
+	current, after := s.pages.closest(key, off/lim+1)
 
-	// declare variables required for storing crucial data
 	var (
-		data        = make([]model.Data, lim)
-		meta        model.Meta
-		numbers     []string
-		next        bool
-		currentPage = 1
+		data       []model.Data
+		meta       = newMeta(query.Pagination)
+		position   = (current - 1) * lim // position of the next match among every match
+		reachedEnd = true
 	)
 
-	/*Fetching paginated data from the database based on state is a bit more complex because that data is non-contiguous. e.g:
-		1. OK
-		2. NOK
-		3. NOK
-		4. OK
-		5. NOK
-		6. OK
-		7. OK
-		8. NOK
-
-	Fetching page 1 of phone numbers which are nok with a limit of 3 puts the final offset for page 1 on number 5 because that's where the third NOK number is.
-
-	In order to fetch page 2, the offset will need to start from number 6 to prevent number 5 from getting included the result set.
-
-	This could be made more efficient by also storing the validity (state) of the phone numbers in the db, this would allow for querying by state directly from the db
-	instead of computing it in the service.
-	Pagination will also be easier since it's certain that the db will return only the state requested not all the states available.
-
-	What this function does is start from page 1 up to page n and try to calculate the start offset to use for page n since it's not really deterministic.
-	*/
-
-	// loop for as long as the current page is lesser than the page for which data is needed
-	// current page always starts as 1.
-	for currentPage <= pg {
-		// Check whether the length of the data is lesser than the limit
-		// this usually executes when the length of data is less than the limit required meaning there's no longer data to be read.
-		if len(data) < lim {
-			data = nil
-			break
-		}
+	// validated as they're read, so a page only ever costs as many records as it takes to fill it
+	err := s.scan(ctx, query.Filter, model.Cursor{Sort: query.Sort, After: after}, func(record model.Record) bool {
+		d := s.toData(record, query.Filter.Search)
 
-		// recover excess data i.e. data after the required limit.
-		// e.g. page 4 returns 1, 2, 3, 4, 5 and our limit is 3
-		// 4, 5 need to be saved because the database query will start from a further offset and returned data will not include them.
-		data = data[lim:]
-
-		// loop for as long as the length of the data we have is lesser than the limit
-		for len(data) < lim {
-
-			numbers, err = s.repository.FetchPaginatedPhoneNumbers(offset, lim+1)
-
-			if err != nil {
-				log.Println(err)
-				return model.Result{}, apperror.ServerError
-			}
-
-			// if returned data from the db is empty
-			if len(numbers) == 0 {
-				next = false // there will be no next page
-				break
-			}
-
-			// if we get extra (lookahead) data then there's extra data to be read
-			if len(numbers) == lim+1 {
-				next = true
-				numbers = numbers[:lim] // truncate the returned data
-			} else {
-				next = false // if we don't get extra (lookahead) data then there's no further data to be read
-			}
-
-			// fill in the data slice with results from the database.
-			for _, number := range numbers {
-				country, code, number, valid := s.validator.Validate(number)
-
-				// ensure that phone number status matches the requested status
-				// i.e. ensure that current phone number is OK and requested status is OK.
-				if (state == "OK" && valid) || (state == "NOK" && !valid) {
-					data = append(data, model.Data{
-						Country:     country,
-						CountryCode: code,
-						PhoneNumber: number,
-						State:       state,
-					})
-				}
-			}
-
-			// increase the offset
-			offset += lim
+		// ensure that phone number status and country match the filter
+		if !matchesComputed(query.Filter, d) {
+			return true
 		}
-		// if the length of data returned is greater than the limit requested, then there's more data to be read from the db.
-		// due to the non-contiguous nature of the data in the db, it's possible for the length of data to be greater than limit.
-		if len(data) > lim {
-			next = true
-		}
-		// increment the current page
-		currentPage++
-	}
 
-	// make the pagination indicators false if the length of data returned is 0
-	if len(data) == 0 {
-		meta.Next = false
-		meta.Prev = false
-	} else {
-		// if the length of returned data is greater than the limit then truncate it to the requested limit
-		if len(data) > lim {
-			data = data[:lim]
-		}
-		meta.Next = next
-		// set the previous flag if the offset is greater than the limit.
-		if (pg*lim - lim) >= lim {
-			meta.Prev = true
+		// a match after the requested results means there's a next page, nothing more needs to be read
+		if position == off+lim {
+			meta.Next = true
+			reachedEnd = false
+
+			return false
 		}
-	}
 
-	meta.CurrentPage = page
+		if position >= off {
+			data = append(data, d)
+		}
 
-	return model.Result{
-		Data: data,
-		Meta: meta,
-	}, nil
+		position++
 
-}
-
-//FilterByCountry : Filter Numbers From The Database By The Country They Belong To.
-func (s *NumberService) FilterByCountry(country, page, limit string) (model.Result, error) {
+		// a page is complete, remember where it ends so the next page can start right after it
+		if position%lim == 0 {
+			s.pages.record(key, generation, position/lim, record)
+		}
 
-	p, lim, err := validateParams("", page, limit)
+		return true
+	})
 
+	// the scan stops as soon as the client is gone, the pages it found so far stay in the page index
 	if err != nil {
-		return model.Result{}, apperror.BadRequest
+		return model.Result{}, err
 	}
 
-	// get the code for the specified country since that's what will be used for the database query
-	code, err := s.validator.GetCodeFromCountry(country)
+	// the last page can only be worked out by a scan which reached the end, otherwise an earlier scan may have found it
+	if reachedEnd {
+		s.pages.finish(key, generation, position)
+	}
 
-	if err != nil {
-		return model.Result{}, apperror.NotFound
+	if count, ok := s.pages.count(key); ok {
+		meta.Count, meta.LastPage = count, lastPage(count, lim)
 	}
 
-	// calculate the offset to be used for fetching data for the requested page
-	offset := lim*p - lim
+	// there's only a previous page if the requested page exists at all
+	if len(data) > 0 && off > 0 {
+		meta.Prev = true
+	}
 
-	var (
-		state   string
-		data    []model.Data
-		meta    model.Meta
-		numbers []string
-		next    bool
-	)
+	return model.Result{
+		Data:   data,
+		Meta:   meta,
+		Fields: query.Fields,
+	}, nil
+}
 
-	// loop until the length of returned data is same as the length of the requested limit
-	for len(data) < lim {
-		numbers, err = s.repository.FetchPaginatedPhoneNumbersByCode(code, offset, lim+1)
+/*sortInMemory : Sorts by fields computed by the service before paginating
+Every matching record has to be read and validated to know where it belongs in the order, the repository is scanned in
+ascending order of ID and the results are then sorted stably.
+*/
+func (s *NumberService) sortInMemory(ctx context.Context, query model.PhoneNumberQuery) (model.Result, error) {
+	off, lim := query.Pagination.Offset, query.Pagination.Limit
 
-		if err != nil {
-			log.Println(err)
-			return model.Result{}, apperror.ServerError
-		}
+	var (
+		rows []row
+		meta = newMeta(query.Pagination)
+	)
 
-		// if results from the database are empty break out of the loop
-		if len(numbers) == 0 {
-			break
+	err := s.validate(ctx, query.Filter, model.Cursor{}, func(record model.Record, d model.Data) bool {
+		if matchesComputed(query.Filter, d) {
+			rows = append(rows, row{record: record, data: d})
 		}
 
-		// if lookahead data is present then set the next flag to true
-		if len(numbers) == lim+1 {
-			next = true
-			numbers = numbers[:lim] // truncate returned data
-		} else {
-			next = false
-		}
+		return true
+	})
 
-		// fill in results from the db into the data object
-		for _, number := range numbers {
-			country, code, number, valid := s.validator.Validate(number)
-			if valid {
-				state = "OK"
-			} else {
-				state = "NOK"
-			}
-			data = append(data, model.Data{
-				Country:     country,
-				CountryCode: code,
-				PhoneNumber: number,
-				State:       state,
-			})
-		}
-		// increase the offset
-		offset += lim
+	if err != nil {
+		return model.Result{}, err
 	}
 
-	meta.Next = next
+	sortRows(rows, query.Sort)
 
-	// use the offset to determine whether there should be a page behind
-	if (p*lim - lim) >= lim {
-		meta.Prev = true
+	var data []model.Data
+
+	// slice out the requested page
+	for index := off; index < len(rows) && index < off+lim; index++ {
+		data = append(data, rows[index].data)
 	}
 
-	// strip the pagination flags if returned data is empty
-	if len(data) == 0 {
-		meta.Next = false
-		meta.Prev = false
+	if len(data) > 0 {
+		meta.Next = len(rows) > off+lim
+		meta.Prev = off > 0
 	}
 
-	meta.CurrentPage = page
+	meta.Count, meta.LastPage = len(rows), lastPage(len(rows), lim)
 
 	return model.Result{
-		Data: data,
-		Meta: meta,
+		Data:   data,
+		Meta:   meta,
+		Fields: query.Fields,
 	}, nil
 }
 
-//FilterByCountryAndState : Filter phone numbers based on the specified country and data
-func (s *NumberService) FilterByCountryAndState(country, state, page, limit string) (model.Result, error) {
-
-	p, lim, err := validateParams(state, page, limit)
-
-	if err != nil {
-		return model.Result{}, apperror.NotFound
+//newMeta : metadata describing which results were requested, before anything is known about the results themselves
+func newMeta(pagination model.Pagination) model.Meta {
+	return model.Meta{
+		CurrentPage: strconv.Itoa(pagination.Page),
+		Offset:      pagination.Offset,
+		Limit:       pagination.Limit,
 	}
+}
 
-	code, err := s.validator.GetCodeFromCountry(country)
-
-	if err != nil {
-		return model.Result{}, apperror.NotFound
+//lastPage : the number of the last page when there are count results, an empty result still has a first page
+func lastPage(count, limit int) int {
+	if count == 0 {
+		return 1
 	}
 
-	// switch the state variable to uppercase
-	state = strings.ToUpper(state)
-
-	// set the offset to 0 because that's what page 1 resolves to (detailed explanation below)
-	offset := 0
-
-	var (
-		data        = make([]model.Data, lim)
-		meta        model.Meta
-		numbers     []string
-		next        bool
-		currentPage = 1
-	)
-
-	/*Fetching paginated data from the database based on status is a bit more complex because that data is non-contiguous. e.g:
-		1. OK
-		2. NOK
-		3. NOK
-		4. OK
-		5. NOK
-		6. OK
-		7. OK
-		8. NOK
+	return (count + limit - 1) / limit
+}
 
-	Fetching page 1 of phone numbers which are nok with a limit of 3 puts the final offset for page 1 on number 5 because that's where the third NOK number is.
+//isComputedFilter : whether the filter depends on what validating a number tells about it
+func isComputedFilter(filter model.Filter) bool {
+	return len(filter.States) > 0 || len(filter.Countries) > 0 || len(filter.ExcludedCountries) > 0
+}
 
-	In order to fetch page 2, the offset will need to start from number 6 in order to prevent number 5 from entering the result set.
+/*matchesComputed : checks a validated number against the parts of the filter the repository can't apply
+A number belongs to the country it's validated as rather than to every country sharing its code, so ?country=canada
+leaves out numbers of the United States even though both are written with (1).
+*/
+func matchesComputed(filter model.Filter, d model.Data) bool {
+	if len(filter.States) > 0 && !contains(filter.States, d.State) {
+		return false
+	}
 
-	What this function does is to start from page 1 up to page n and try to calculate the start offset to use for page n since it's not really deterministic.
+	if len(filter.Countries) > 0 && !containsFold(filter.Countries, d.Country) {
+		return false
+	}
 
-	This could be made more efficient by also storing the validity (state) of the phone numbers in the db, this would allow for querying by state directly from the db
-	instead of computing it in the service.
-	Pagination will also be easier since it's certain that the db will return only the state requested not all the states available.
+	return !containsFold(filter.ExcludedCountries, d.Country)
+}
 
-	This block runs in 0(N^2) Time
-	*/
+//codesFor : Resolves the dialling code of every country provided
+func (s *NumberService) codesFor(countries []string) ([]string, error) {
+	var codes []string
 
-	// loop until the current page is equal to the required page
-	for currentPage <= p {
-		// Results From The Previous Page Are Lesser Than The Limit, Meaning There's No More Data To Be Read From The DB.
-		if len(data) < lim {
-			data = nil
-			break
-		}
+	for _, country := range countries {
+		code, err := s.validator.GetCodeFromCountry(country)
 
-		// recover excess data i.e. data after the required limit.
-		// e.g. page 4 returns 1, 2, 3, 4, 5 and our limit is 3
-		// 4, 5 need to be saved because the database query will start from a further offset and returned data will not include them.
-		data = data[lim:]
-
-		// loop until the data is equal to the limit
-		for len(data) < lim {
-			numbers, err = s.repository.FetchPaginatedPhoneNumbersByCode(code, offset, lim+1)
-
-			if err != nil {
-				log.Println(err)
-				return model.Result{}, apperror.ServerError
-			}
-
-			// there will be no next page if returned result from the db is empty, so exit the loop
-			if len(numbers) == 0 {
-				next = false
-				break
-			}
-
-			// if we get extra (lookahead) data then there's extra data to be read
-			if len(numbers) == lim+1 {
-				next = true
-				numbers = numbers[:lim] // truncate the returned data
-			} else {
-				next = false
-			}
-
-			// fill in the data slice with results from the database.
-			for _, number := range numbers {
-				country, code, number, valid := s.validator.Validate(number)
-
-				// ensure that phone number status matches the requested status
-				// i.e. ensure that current phone number is OK and requested status is OK.
-				if (state == "OK" && valid) || (state == "NOK" && !valid) {
-					data = append(data, model.Data{
-						Country:     country,
-						CountryCode: code,
-						PhoneNumber: number,
-						State:       state,
-					})
-				}
-			}
-			// calculate the offset for the next page (offset + limit)
-			offset += lim
-		}
-		if len(data) > lim {
-			next = true
+		if err != nil {
+			return nil, apperror.NotFound
 		}
 
-		// go on to the next page
-		currentPage++
+		codes = append(codes, code)
 	}
 
-	// make the pagination indicators false if the length of data returned is 0
-	if len(data) == 0 {
-		meta.Next = false
-		meta.Prev = false
-	} else {
-		// if the length of returned data is greater than the limit then truncate it to the requested limit
-		if len(data) > lim {
-			data = data[:lim]
-		}
-		meta.Next = next
+	return codes, nil
+}
 
-		// set the previous flag if the offset is greater than the limit.
-		if (p*lim - lim) >= lim {
-			meta.Prev = true
-		}
-	}
+//toData : Runs a stored phone number through the validator and converts it into the shape returned to clients
+func (s *NumberService) toData(record model.Record, search model.Search) model.Data {
+	country, code, number, valid := s.validator.Validate(record.Phone)
 
-	meta.CurrentPage = page
+	state := "OK"
 
-	return model.Result{
-		Data: data,
-		Meta: meta,
-	}, nil
+	// if the state of the phone number is not valid then set it as Not Okay (NOK)
+	if !valid {
+		state = "NOK"
+	}
+
+	return model.Data{
+		ID:          record.ID,
+		Name:        strings.TrimSpace(record.Name),
+		Country:     country,
+		CountryCode: code,
+		PhoneNumber: number,
+		State:       state,
+		Highlights:  highlight(search, record.Name, number),
+	}
 }
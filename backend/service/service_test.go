@@ -1,11 +1,19 @@
 package service
 
 import (
+	"assessment/apperror"
+	"assessment/config"
+	"assessment/infra/db/memory"
+	"assessment/model"
 	repoMock "assessment/repository/mock"
 	serviceMock "assessment/service/mock"
+	"context"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"net/url"
 	"testing"
+	"time"
 )
 
 type testSuite struct {
@@ -15,88 +23,150 @@ type testSuite struct {
 
 func (t *testSuite) SetupSuite() {
 	mockRepo := new(repoMock.PhoneNumberRepository)
+	mockRepo.On("LastModified", mock.Anything).Return(time.Time{}, nil)
 	mockValidator := new(serviceMock.NumberValidator)
 
 	mockValidator.On("Validate", "(237) 697151594").Return("Cameroon", "+237", "697151594", true)
 	mockValidator.On("Validate", "(237) 699209115").Return("Cameroon", "+237", "699209115", false)
 	mockValidator.On("GetCodeFromCountry", "cameroon").Return("237", nil)
+	mockValidator.On("GetCodeFromCountry", "uganda").Return("256", nil)
+	mockValidator.On("GetCodeFromCountry", "morocco").Return("212", nil)
+	mockValidator.On("GetCodeFromCountry", "nigeria").Return("", apperror.NotFound)
+
+	var (
+		ok  = "(237) 697151594"
+		nok = "(237) 699209115"
+
+		onlyOK     = model.Filter{States: []string{"OK"}}
+		onlyNOK    = model.Filter{States: []string{"NOK"}}
+		cameroon   = model.Filter{Countries: []string{"cameroon"}, Codes: []string{"237"}}
+		cameroonOK = model.Filter{Countries: []string{"cameroon"}, Codes: []string{"237"}, States: []string{"OK"}}
+	)
 
 	// ============== Test Data For All Phone Numbers  ===================== \\
-	mockRepo.On("FetchPaginatedPhoneNumbers", 0, 6).Return([]string{
-		"(237) 697151594",
-		"(237) 697151594",
-		"(237) 697151594",
-		"(237) 697151594",
-		"(237) 697151594",
-		"(237) 697151594",
-	}, nil)
+	mockRepo.On("FetchPhoneNumbers", mock.Anything, model.Filter{}, model.Cursor{Offset: 0, Limit: 6}).
+		Return(records(ok, ok, ok, ok, ok, ok), nil)
+	mockRepo.On("FetchPhoneNumbers", mock.Anything, model.Filter{}, model.Cursor{Offset: 5, Limit: 6}).
+		Return(records(ok, ok, ok, ok, ok, ok), nil)
+	mockRepo.On("FetchPhoneNumbers", mock.Anything, model.Filter{}, model.Cursor{Offset: 10, Limit: 6}).
+		Return(records(ok, ok, ok), nil)
+	mockRepo.On("FetchPhoneNumbers", mock.Anything, model.Filter{}, model.Cursor{Offset: 15, Limit: 6}).
+		Return(records(), nil)
+	mockRepo.On("FetchPhoneNumbers", mock.Anything, model.Filter{}, model.Cursor{Offset: 0, Limit: 3}).
+		Return(records(nok, nok), nil)
+	// ============================================================================== \\
 
-	mockRepo.On("FetchPaginatedPhoneNumbers", 5, 6).Return([]string{
-		"(237) 697151594",
-		"(237) 697151594",
-		"(237) 697151594",
-		"(237) 697151594",
-		"(237) 697151594",
-		"(237) 697151594",
-	}, nil)
+	// =========================== Test Data For Filter By State And Filter By Country ==================== \\
+	mockRepo.On("ScanPhoneNumbers", mock.Anything, onlyOK, mock.Anything, mock.Anything).
+		Run(scanTable(ok, ok, ok, ok, ok, ok)).Return(nil)
+	mockRepo.On("ScanPhoneNumbers", mock.Anything, onlyNOK, mock.Anything, mock.Anything).
+		Run(scanTable(nok, nok, nok, nok, nok)).Return(nil)
+	mockRepo.On("ScanPhoneNumbers", mock.Anything, cameroon, mock.Anything, mock.Anything).
+		Run(scanTable(ok, ok, ok, ok, ok)).Return(nil)
+	// ============================================================================== \\
 
-	mockRepo.On("FetchPaginatedPhoneNumbers", 10, 6).Return([]string{
-		"(237) 697151594",
-		"(237) 697151594",
-		"(237) 697151594",
-	}, nil)
+	// ============================ Test Data For Filter By Country And State ====================== \\
+	mockRepo.On("ScanPhoneNumbers", mock.Anything, cameroonOK, mock.Anything, mock.Anything).
+		Run(scanTable(ok, nok, ok, nok, nok, ok, ok, nok)).Return(nil)
 
-	mockRepo.On("FetchPaginatedPhoneNumbers", 15, 6).Return([]string{}, nil)
+	// ============================ Test Data For Multiple And Excluded Countries ====================== \\
+	mockRepo.On("ScanPhoneNumbers", mock.Anything, model.Filter{
+		Countries:         []string{"cameroon", "uganda"},
+		Codes:             []string{"237", "256"},
+		ExcludedCountries: []string{"morocco"},
+		States:            []string{"NOK"},
+	}, mock.Anything, mock.Anything).Run(scanTable(ok, nok, nok, ok)).Return(nil)
 
-	mockRepo.On("FetchPaginatedPhoneNumbers", 0, 3).Return([]string{
-		"(237) 699209115",
-		"(237) 699209115",
-	}, nil)
-	// ============================================================================== \\
+	// ============================ Test Data For Searches ====================== \\
+	mockRepo.On("FetchPhoneNumbers", mock.Anything, model.Filter{Search: model.Search{Number: "97151"}}, model.Cursor{Limit: 6}).
+		Return([]model.Record{{ID: 31, Name: "Emile Christian", Phone: ok}}, nil)
+
+	mockRepo.On("CountPhoneNumbers", mock.Anything, model.Filter{Search: model.Search{Number: "97151"}}).Return(1, nil)
+	mockRepo.On("CountPhoneNumbers", mock.Anything, mock.Anything).Return(13, nil)
 
-	// =========================== Test Data For Filter By State And Filter By Country ==================== \\
-	mockRepo.On("FetchPaginatedPhoneNumbers", 0, 11).Return([]string{
-		"(237) 699209115",
-		"(237) 699209115",
-		"(237) 699209115",
-		"(237) 699209115",
-		"(237) 699209115",
-	}, nil)
-	mockRepo.On("FetchPaginatedPhoneNumbers", 10, 11).Return([]string{}, nil)
 	t.svc = NewNumberService(mockValidator, mockRepo)
+}
 
-	// ============================================================================== \\
+// records : numbers the phones provided the way the database would
+func records(phones ...string) []model.Record {
+	result := make([]model.Record, 0, len(phones))
 
-	// ============================ Test Data For Filter By Country And State ====================== \\
-	mockRepo.On("FetchPaginatedPhoneNumbersByCode", "237", 0, 5).Return([]string{
-		"(237) 697151594",
-		"(237) 697151594",
-		"(237) 697151594",
-		"(237) 697151594",
-		"(237) 697151594",
-	}, nil)
+	for index, phone := range phones {
+		result = append(result, model.Record{ID: int64(index + 1), Phone: phone})
+	}
 
-	mockRepo.On("FetchPaginatedPhoneNumbersByCode", "237", 0, 4).Return([]string{
-		"(237) 697151594",
-		"(237) 699209115",
-		"(237) 697151594",
-		"(237) 699209115",
-	}, nil)
-	mockRepo.On("FetchPaginatedPhoneNumbersByCode", "237", 3, 4).Return([]string{
-		"(237) 699209115",
-		"(237) 697151594",
-		"(237) 697151594",
-		"(237) 699209115",
-	}, nil)
+	return result
+}
+
+// table : simulates a table holding the phones provided which can be read from any cursor
+func table(phones ...string) func(context.Context, model.Filter, model.Cursor) []model.Record {
+	rows := records(phones...)
+
+	return func(_ context.Context, _ model.Filter, cursor model.Cursor) []model.Record {
+		var result []model.Record
+
+		for _, row := range rows {
+			if cursor.After == nil || row.ID > cursor.After.ID {
+				result = append(result, row)
+			}
+		}
+
+		if cursor.Offset >= len(result) {
+			return nil
+		}
+
+		result = result[cursor.Offset:]
+
+		if len(result) > cursor.Limit {
+			result = result[:cursor.Limit]
+		}
+
+		return result
+	}
+}
+
+// scanTable : simulates scanning a table holding the phones provided from any cursor, stopping once yield does
+func scanTable(phones ...string) func(mock.Arguments) {
+	read := table(phones...)
+
+	return func(args mock.Arguments) {
+		cursor := args.Get(2).(model.Cursor)
 
+		// scans read to the end without a limit
+		if cursor.Limit <= 0 {
+			cursor.Limit = len(phones)
+		}
+
+		yield := args.Get(3).(func(model.Record) bool)
+
+		for _, record := range read(args.Get(0).(context.Context), args.Get(1).(model.Filter), cursor) {
+			if !yield(record) {
+				return
+			}
+		}
+	}
 }
 
 func TestServiceSuite(t *testing.T) {
 	suite.Run(t, new(testSuite))
 }
 
-func (t *testSuite) Test_FetchPhoneNumbers() {
-	result, err := t.svc.FetchPhoneNumbers("1", "5")
+// query : parses the raw query string the way the controller would and runs it through the service
+func (t *testSuite) query(raw string) (model.Result, error) {
+	values, err := url.ParseQuery(raw)
+	require.NoError(t.T(), err)
+
+	query, err := ParseQuery(values, config.PageSize{})
+
+	if err != nil {
+		return model.Result{}, err
+	}
+
+	return t.svc.Query(context.Background(), query)
+}
+
+func (t *testSuite) Test_QueryPhoneNumbers() {
+	result, err := t.query("page=1&limit=5")
 
 	require.NoError(t.T(), err, "Expected: nil\nGot: %v\n", err)
 
@@ -104,7 +174,7 @@ func (t *testSuite) Test_FetchPhoneNumbers() {
 		require.Equal(t.T(), "OK", d.State)
 	}
 
-	result, err = t.svc.FetchPhoneNumbers("1", "2")
+	result, err = t.query("page=1&limit=2")
 
 	require.NoError(t.T(), err, "Expected: nil\nGot: %v\n", err)
 
@@ -116,7 +186,7 @@ func (t *testSuite) Test_FetchPhoneNumbers() {
 	require.Equal(t.T(), false, result.Meta.Next)
 	require.Equal(t.T(), false, result.Meta.Prev)
 
-	result, err = t.svc.FetchPhoneNumbers("2", "5")
+	result, err = t.query("page=2&limit=5")
 
 	require.NoError(t.T(), err, "Expected: nil\nGot: %v\n", err)
 
@@ -124,15 +194,16 @@ func (t *testSuite) Test_FetchPhoneNumbers() {
 	require.Equal(t.T(), true, result.Meta.Next)
 	require.Equal(t.T(), true, result.Meta.Prev)
 
-	result, err = t.svc.FetchPhoneNumbers("3", "5")
+	result, err = t.query("page=3&limit=5")
 
 	require.NoError(t.T(), err, "Expected: nil\nGot: %v\n", err)
 
 	require.Equal(t.T(), 3, len(result.Data))
 	require.Equal(t.T(), false, result.Meta.Next)
 	require.Equal(t.T(), true, result.Meta.Prev)
+	require.Equal(t.T(), 3, result.Meta.LastPage)
 
-	result, err = t.svc.FetchPhoneNumbers("", "")
+	result, err = t.query("")
 
 	require.NoError(t.T(), err, "Expected: nil\nGot: %v\n", err)
 
@@ -140,7 +211,7 @@ func (t *testSuite) Test_FetchPhoneNumbers() {
 	require.Equal(t.T(), true, result.Meta.Next)
 	require.Equal(t.T(), false, result.Meta.Prev)
 
-	result, err = t.svc.FetchPhoneNumbers("4", "5")
+	result, err = t.query("page=4&limit=5")
 
 	require.NoError(t.T(), err, "Expected: nil\nGot: %v\n", err)
 
@@ -148,26 +219,18 @@ func (t *testSuite) Test_FetchPhoneNumbers() {
 	require.Equal(t.T(), false, result.Meta.Next)
 	require.Equal(t.T(), false, result.Meta.Prev)
 
-	result, err = t.svc.FetchPhoneNumbers("-1", "4")
-
-	require.Error(t.T(), err, "Expected An Error\nGot: %v\n", err)
-
-	result, err = t.svc.FetchPhoneNumbers("1", "-4")
-
-	require.Error(t.T(), err, "Expected An Error\nGot: %v\n", err)
-
 }
 
-func (t *testSuite) Test_FilterByState() {
+func (t *testSuite) Test_QueryByState() {
 
-	result, err := t.svc.FilterByState("OK", "1", "5")
+	result, err := t.query("state=OK&page=1&limit=5")
 	require.NoError(t.T(), err, "Expected: nil\nGot: %v\n", err)
 
 	for _, d := range result.Data {
 		require.Equal(t.T(), "OK", d.State)
 	}
 
-	result, err = t.svc.FilterByState("NOK", "1", "10")
+	result, err = t.query("state=NOK&page=1&limit=10")
 	require.NoError(t.T(), err, "Expected: nil\nGot: %v\n", err)
 
 	for _, d := range result.Data {
@@ -176,57 +239,392 @@ func (t *testSuite) Test_FilterByState() {
 
 	require.Equal(t.T(), false, result.Meta.Next)
 	require.Equal(t.T(), false, result.Meta.Prev)
+}
 
-	_, err = t.svc.FilterByState("INVALID", "1", "10")
-	require.Error(t.T(), err, "Expected An Error\nGot: %v\n", err)
+func (t *testSuite) Test_QueryByCountry() {
+	result, err := t.query("country=cameroon&page=1&limit=4")
+	require.NoError(t.T(), err, "Expected: nil\nGot: %v\n", err)
+
+	for _, d := range result.Data {
+		require.Equal(t.T(), "Cameroon", d.Country)
+		require.Equal(t.T(), "+237", d.CountryCode)
+	}
+}
+
+func (t *testSuite) Test_QueryByCountryAndState() {
+	result, err := t.query("country=cameroon&state=OK&page=1&limit=3")
+	require.NoError(t.T(), err, "Expected: nil\nGot: %v\n", err)
 
-	_, err = t.svc.FilterByState("VALID", "1", "10")
+	for _, d := range result.Data {
+		require.Equal(t.T(), 3, len(result.Data))
+		require.Equal(t.T(), "Cameroon", d.Country)
+		require.Equal(t.T(), "+237", d.CountryCode)
+		require.Equal(t.T(), "OK", d.State)
+		require.Equal(t.T(), true, result.Meta.Next)
+	}
+
+	// the page is optional when filtering by both country and state
+	result, err = t.query("country=cameroon&state=OK&limit=3")
+	require.NoError(t.T(), err, "Expected: nil\nGot: %v\n", err)
+	require.Equal(t.T(), 3, len(result.Data))
+	require.Equal(t.T(), "1", result.Meta.CurrentPage)
+
+	_, err = t.query("country=cameroon&state=MOK&page=1&limit=3")
 	require.Error(t.T(), err, "Expected An Error\nGot: %v\n", err)
 
-	_, err = t.svc.FilterByState("INVALID", "-1", "10")
+	_, err = t.query("country=cameroon&state=NOK&page=-1&limit=3")
 	require.Error(t.T(), err, "Expected An Error\nGot: %v\n", err)
 
-	_, err = t.svc.FilterByState("INVALID", "1", "10a")
+	_, err = t.query("country=cameroon&state=NOK&page=1&limit=jumia")
 	require.Error(t.T(), err, "Expected An Error\nGot: %v\n", err)
 
 }
 
-func (t *testSuite) Test_FilterByCountry() {
-	result, err := t.svc.FilterByCountry("cameroon", "1", "4")
+func TestNumberService_QueryByStatePageIndex(t *testing.T) {
+	mockRepo := new(repoMock.PhoneNumberRepository)
+	mockRepo.On("LastModified", mock.Anything).Return(time.Time{}, nil)
+
+	// NOK numbers are at IDs 2, 3, 5, 8, 9 and 10
+	mockRepo.On("ScanPhoneNumbers", mock.Anything, model.Filter{States: []string{"NOK"}}, mock.Anything, mock.Anything).Run(scanTable(
+		"(237) 697151594",
+		"(237) 100000002",
+		"(237) 100000003",
+		"(237) 677046616",
+		"(237) 100000005",
+		"(237) 673122155",
+		"(237) 695539786",
+		"(237) 100000008",
+		"(237) 100000009",
+		"(237) 100000010",
+	)).Return(nil)
+
+	svc := NewNumberService(NewValidator(), mockRepo)
+
+	query := model.PhoneNumberQuery{
+		Filter:     model.Filter{States: []string{"NOK"}},
+		Pagination: model.Pagination{Limit: 2},
+	}
+
+	var expected = []struct {
+		numbers    []string
+		next, prev bool
+	}{
+		{[]string{"100000002", "100000003"}, true, false},
+		{[]string{"100000005", "100000008"}, true, true},
+		{[]string{"100000009", "100000010"}, false, true},
+		{nil, false, false},
+	}
+
+	// every page is read in order, with no number repeated or skipped across page boundaries
+	for index, page := range expected {
+		query.Pagination.Page = index + 1
+		query.Pagination.Offset = index * query.Pagination.Limit
+
+		result, err := svc.Query(context.Background(), query)
+		require.NoError(t, err, "Expected: nil\nGot: %v\n", err)
+
+		var numbers []string
+
+		for _, d := range result.Data {
+			require.Equal(t, "NOK", d.State)
+			numbers = append(numbers, d.PhoneNumber)
+		}
+
+		require.Equal(t, page.numbers, numbers)
+		require.Equal(t, page.next, result.Meta.Next)
+		require.Equal(t, page.prev, result.Meta.Prev)
+	}
+
+	// going past the end means the last page is now known
+	count, ok := svc.pages.count(pageIndexKey(query))
+	require.True(t, ok)
+	require.Equal(t, 3, lastPage(count, query.Pagination.Limit))
+
+	// page 3 has been reached before, so the scan resumes right after the last number on page 2
+	mockRepo.Calls = nil
+	query.Pagination.Page = 3
+	query.Pagination.Offset = 4
+
+	result, err := svc.Query(context.Background(), query)
+	require.NoError(t, err, "Expected: nil\nGot: %v\n", err)
+	require.Equal(t, 2, len(result.Data))
+	require.Equal(t, int64(8), mockRepo.Calls[len(mockRepo.Calls)-1].Arguments.Get(2).(model.Cursor).After.ID)
+}
+
+func (t *testSuite) Test_QueryByMultipleCountries() {
+	result, err := t.query("country=cameroon,uganda&country!=morocco&state=NOK")
 	require.NoError(t.T(), err, "Expected: nil\nGot: %v\n", err)
+	require.Equal(t.T(), 2, len(result.Data))
 
 	for _, d := range result.Data {
-		require.Equal(t.T(), "Cameroon", d.Country)
-		require.Equal(t.T(), "+237", d.CountryCode)
+		require.Equal(t.T(), "NOK", d.State)
 	}
 
-	_, err = t.svc.FilterByCountry("cameroon", "-1", "4")
-	require.Error(t.T(), err, "Expected An Error\nGot: %v\n", err)
+	_, err = t.query("country=cameroon&country=nigeria")
+	require.Equal(t.T(), apperror.NotFound, err)
 
-	_, err = t.svc.FilterByCountry("cameroon", "1", "4+")
-	require.Error(t.T(), err, "Expected An Error\nGot: %v\n", err)
+	_, err = t.query("country!=nigeria")
+	require.Equal(t.T(), apperror.NotFound, err)
+}
 
+func TestParseQuery(t *testing.T) {
+	values, err := url.ParseQuery("country=cameroon,uganda&country=morocco&country=uganda&country!=ethiopia&state=NOK")
+	require.NoError(t, err)
+
+	query, err := ParseQuery(values, config.PageSize{})
+	require.NoError(t, err, "Expected: nil\nGot: %v\n", err)
+
+	require.Equal(t, []string{"cameroon", "uganda", "morocco"}, query.Filter.Countries)
+	require.Equal(t, []string{"ethiopia"}, query.Filter.ExcludedCountries)
+	require.Equal(t, []string{"NOK"}, query.Filter.States)
+	require.Equal(t, model.Pagination{Page: 1, Offset: 0, Limit: 5}, query.Pagination)
+
+	// both states together don't filter anything out
+	values, _ = url.ParseQuery("state=OK,NOK")
+
+	query, err = ParseQuery(values, config.PageSize{})
+	require.NoError(t, err, "Expected: nil\nGot: %v\n", err)
+	require.Empty(t, query.Filter.States)
 }
 
-func (t *testSuite) Test_FilterByCountryAndState() {
-	result, err := t.svc.FilterByCountryAndState("cameroon", "OK", "1", "3")
+func (t *testSuite) Test_QueryBySearch() {
+	result, err := t.query("q=97151")
 	require.NoError(t.T(), err, "Expected: nil\nGot: %v\n", err)
+	require.Equal(t.T(), 1, len(result.Data))
+
+	d := result.Data[0]
+	require.Equal(t.T(), int64(31), d.ID)
+	require.Equal(t.T(), "Emile Christian", d.Name)
+	require.Equal(t.T(), &model.Highlights{PhoneNumber: "6<mark>97151</mark>594"}, d.Highlights)
+}
+
+func TestNumberService_QueryByStateWithOffset(t *testing.T) {
+	mockRepo := new(repoMock.PhoneNumberRepository)
+	mockRepo.On("LastModified", mock.Anything).Return(time.Time{}, nil)
+
+	// NOK numbers are at IDs 2, 3, 5 and 6
+	mockRepo.On("ScanPhoneNumbers", mock.Anything, model.Filter{States: []string{"NOK"}}, mock.Anything, mock.Anything).Run(scanTable(
+		"(237) 697151594",
+		"(237) 100000002",
+		"(237) 100000003",
+		"(237) 677046616",
+		"(237) 100000005",
+		"(237) 100000006",
+	)).Return(nil)
+
+	svc := NewNumberService(NewValidator(), mockRepo)
+
+	// an offset which doesn't fall on a page boundary spans two pages
+	result, err := svc.Query(context.Background(), model.PhoneNumberQuery{
+		Filter:     model.Filter{States: []string{"NOK"}},
+		Pagination: model.Pagination{Page: 1, Offset: 1, Limit: 2},
+	})
+	require.NoError(t, err, "Expected: nil\nGot: %v\n", err)
+
+	var numbers []string
 
 	for _, d := range result.Data {
-		require.Equal(t.T(), 3, len(result.Data))
-		require.Equal(t.T(), "Cameroon", d.Country)
-		require.Equal(t.T(), "+237", d.CountryCode)
-		require.Equal(t.T(), "OK", d.State)
-		require.Equal(t.T(), true, result.Meta.Next)
+		numbers = append(numbers, d.PhoneNumber)
 	}
 
-	_, err = t.svc.FilterByCountryAndState("cameroon", "MOK", "1", "3")
-	require.Error(t.T(), err, "Expected An Error\nGot: %v\n", err)
+	require.Equal(t, []string{"100000003", "100000005"}, numbers)
+	require.True(t, result.Meta.Next)
+	require.True(t, result.Meta.Prev)
+	require.Equal(t, 1, result.Meta.Offset)
+	require.Equal(t, 2, result.Meta.Limit)
+}
 
-	_, err = t.svc.FilterByCountryAndState("cameroon", "NOK", "-1", "3")
-	require.Error(t.T(), err, "Expected An Error\nGot: %v\n", err)
+func TestNumberService_QueryTimeout(t *testing.T) {
+	mockRepo := new(repoMock.PhoneNumberRepository)
+	mockRepo.On("LastModified", mock.Anything).Return(time.Time{}, nil)
 
-	_, err = t.svc.FilterByCountryAndState("cameroon", "NOK", "1", "jumia")
-	require.Error(t.T(), err, "Expected An Error\nGot: %v\n", err)
+	// a query which only returns once it is told to give up
+	mockRepo.On("FetchPhoneNumbers", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { <-args.Get(0).(context.Context).Done() }).
+		Return(nil, context.DeadlineExceeded)
+
+	svc := NewNumberService(NewValidator(), mockRepo, WithQueryTimeout(10*time.Millisecond))
+
+	_, err := svc.Query(context.Background(), model.PhoneNumberQuery{Pagination: model.Pagination{Page: 1, Limit: 5}})
+	require.Equal(t, apperror.Timeout, err)
+}
+
+//TestNumberService_LastModifiedTimeout : a hung repository can't hold the request up before its query even starts
+func TestNumberService_LastModifiedTimeout(t *testing.T) {
+	mockRepo := new(repoMock.PhoneNumberRepository)
+	modified := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
 
+	mockRepo.On("LastModified", mock.Anything).
+		Run(func(args mock.Arguments) { <-args.Get(0).(context.Context).Done() }).
+		Return(time.Time{}, context.DeadlineExceeded).Once()
+	mockRepo.On("LastModified", mock.Anything).Return(modified, nil)
+	mockRepo.On("FetchPhoneNumbers", mock.Anything, model.Filter{}, mock.Anything).Return(table("(237) 697151594"), nil)
+	mockRepo.On("CountPhoneNumbers", mock.Anything, model.Filter{}).Return(1, nil)
+
+	svc := NewNumberService(NewValidator(), mockRepo, WithQueryTimeout(10*time.Millisecond))
+	query := model.PhoneNumberQuery{Pagination: model.Pagination{Page: 1, Limit: 5}}
+
+	// when it isn't known, the data is served without a modification time
+	result, err := svc.Query(context.Background(), query)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.True(t, result.LastModified.IsZero())
+
+	result, err = svc.Query(context.Background(), query)
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Equal(t, modified, result.LastModified)
+
+	// read once per query
+	mockRepo.AssertNumberOfCalls(t, "LastModified", 2)
+}
+
+func TestNumberService_QueryCanceled(t *testing.T) {
+	mockRepo := new(repoMock.PhoneNumberRepository)
+	mockRepo.On("LastModified", mock.Anything).Return(time.Time{}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the client disconnects while the scan is under way, which interrupts it
+	mockRepo.On("ScanPhoneNumbers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			cancel()
+			<-args.Get(0).(context.Context).Done()
+		}).
+		Return(context.Canceled)
+
+	svc := NewNumberService(NewValidator(), mockRepo)
+
+	_, err := svc.Query(ctx, model.PhoneNumberQuery{
+		Filter:     model.Filter{States: []string{"NOK"}},
+		Pagination: model.Pagination{Page: 1, Limit: 1},
+	})
+	require.Equal(t, apperror.Canceled, err)
+
+	// the query is stopped rather than left to finish for nobody
+	require.Eventually(t, func() bool {
+		svc.flights.mu.Lock()
+		defer svc.flights.mu.Unlock()
+
+		return len(svc.flights.flights) == 0
+	}, 5*time.Second, time.Millisecond)
+
+	mockRepo.AssertNumberOfCalls(t, "ScanPhoneNumbers", 1)
+}
+
+func TestNumberService_QueryBySharedCode(t *testing.T) {
+	// the United States, Canada and the Bahamas share the code 1, Russia and Kazakhstan share 7
+	repo := memory.NewRepo([]model.Record{
+		{ID: 1, Name: "New Jersey", Phone: "(1) 2015550123"},
+		{ID: 2, Name: "Ottawa", Phone: "(1) 6135550123"},
+		{ID: 3, Name: "Nassau", Phone: "(1) 2423591234"},
+		{ID: 4, Name: "Moscow", Phone: "(7) 4951234567"},
+		{ID: 5, Name: "Almaty", Phone: "(7) 7012345678"},
+		{ID: 6, Name: "Yaounde", Phone: "(237) 697151594"},
+		{ID: 7, Name: "Nowhere", Phone: "(1) 0000000000"},
+		{ID: 8, Name: "Freeport", Phone: "(1242) 3521234"},
+	})
+
+	svc := NewNumberService(NewValidator(), repo)
+
+	ids := func(raw string) []int64 {
+		values, err := url.ParseQuery(raw + "&limit=10")
+		require.NoError(t, err, "Expected: nil\nGot: %v\n", err)
+
+		query, err := ParseQuery(values, config.PageSize{})
+		require.NoError(t, err, "Expected: nil\nGot: %v\n", err)
+
+		result, err := svc.Query(context.Background(), query)
+		require.NoError(t, err, "Expected: nil\nGot: %v\n", err)
+
+		found := []int64{}
+
+		for _, d := range result.Data {
+			found = append(found, d.ID)
+		}
+
+		return found
+	}
+
+	// a country only gets the numbers validated as its own, not every number written with its code, numbers which
+	// aren't valid anywhere belong to the main country of their code
+	require.Equal(t, []int64{1, 7}, ids("country=united states"))
+	require.Equal(t, []int64{2}, ids("country=Canada"))
+	require.Equal(t, []int64{3, 8}, ids("country=bahamas"))
+	require.Equal(t, []int64{4}, ids("country=russia"))
+	require.Equal(t, []int64{5}, ids("country=kazakhstan"))
+	require.Equal(t, []int64{2, 3, 8}, ids("country=canada,bahamas"))
+	require.Equal(t, []int64{1}, ids("country=united states&state=OK"))
+
+	// excluding a country leaves the others sharing its code in
+	require.Equal(t, []int64{1, 3, 4, 5, 6, 7, 8}, ids("country!=canada"))
+	require.Equal(t, []int64{1, 2, 3, 5, 6, 7, 8}, ids("country!=russia"))
+	require.Equal(t, []int64{2, 4}, ids("country=canada,bahamas,russia&country!=bahamas"))
+
+	// the same goes for countries sorted by the service
+	require.Equal(t, []int64{3, 8, 2}, ids("country=canada,bahamas&sort=country"))
+
+	// a number written with more digits in brackets than its country's code has belongs to the country it's validated as
+	require.Equal(t, []int64{8}, ids("country=bahamas&q=3521234"))
+	require.Equal(t, []int64{}, ids("country=united states&q=3521234"))
+	require.Equal(t, []int64{6, 2, 5, 4, 1, 7}, ids("country!=bahamas&sort=country"))
+}
+
+func TestNumberService_QueryByStateReadsOnePage(t *testing.T) {
+	mockRepo := new(repoMock.PhoneNumberRepository)
+	mockRepo.On("LastModified", mock.Anything).Return(time.Time{}, nil)
+
+	phones := make([]string, 4*validationBatchSize)
+
+	for i := range phones {
+		phones[i] = "(237) 697151594"
+	}
+
+	var read int
+
+	mockRepo.On("ScanPhoneNumbers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			yield := args.Get(3).(func(model.Record) bool)
+
+			for _, record := range records(phones...) {
+				read++
+
+				if !yield(record) {
+					return
+				}
+			}
+		}).
+		Return(nil)
+
+	svc := NewNumberService(NewValidator(), mockRepo, WithValidationWorkers(4))
+
+	result, err := svc.Query(context.Background(), model.PhoneNumberQuery{
+		Filter:     model.Filter{States: []string{"OK"}},
+		Pagination: model.Pagination{Page: 1, Limit: 5},
+	})
+	require.NoError(t, err, "Expected: nil\nGot: %v\n", err)
+	require.Len(t, result.Data, 5)
+
+	// a page reads the records filling it and the one telling there's a next page, however many workers there are
+	require.Equal(t, 6, read)
+}
+
+func TestNumberService_QueryValidatorPanics(t *testing.T) {
+	mockRepo := new(repoMock.PhoneNumberRepository)
+	mockRepo.On("LastModified", mock.Anything).Return(time.Time{}, nil)
+	mockRepo.On("ScanPhoneNumbers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(scanTable("(237) 697151594", "(237) 699209115")).Return(nil)
+
+	mockValidator := new(serviceMock.NumberValidator)
+	mockValidator.On("Validate", mock.Anything).Panic("broken rule")
+
+	for _, workers := range []int{1, 4} {
+		svc := NewNumberService(mockValidator, mockRepo, WithValidationWorkers(workers))
+
+		// the query fails rather than the process
+		_, err := svc.Query(context.Background(), model.PhoneNumberQuery{
+			Sort:       []model.SortKey{{Field: "country"}},
+			Pagination: model.Pagination{Page: 1, Limit: 5},
+		})
+		require.Equal(t, apperror.ServerError, err)
+	}
 }
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"assessment/model"
+	"assessment/repository"
+	"sort"
+	"strings"
+)
+
+// sortFields : the fields results can be sorted by, country and state aren't stored so they're sorted by the service
+var sortFields = map[string]bool{
+	"id":      false,
+	"name":    false,
+	"phone":   false,
+	"country": true,
+	"state":   true,
+}
+
+/*parseSort : reads the sort keys requested by the client
+Keys are comma separated and prefixed with - for descending order, e.g. country,-name sorts by country and then by name
+in reverse alphabetical order. The OpenAPI document only lets the keys of SortFields through.
+*/
+func parseSort(keys []string) []model.SortKey {
+	var (
+		result []model.SortKey
+		seen   = make(map[string]bool)
+	)
+
+	for _, key := range keys {
+		sortKey := model.SortKey{Field: strings.TrimPrefix(key, "-"), Descending: strings.HasPrefix(key, "-")}
+
+		// only the first mention of a field has any effect on the order
+		if _, ok := sortFields[sortKey.Field]; !ok || seen[sortKey.Field] {
+			continue
+		}
+
+		seen[sortKey.Field] = true
+		result = append(result, sortKey)
+	}
+
+	return result
+}
+
+//SortFields : the fields results can be sorted by, in alphabetical order
+func SortFields() []string {
+	var fields []string
+
+	for field := range sortFields {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	return fields
+}
+
+//isComputedSort : checks whether any of the keys sorts by a field which isn't stored in the repository
+func isComputedSort(keys []model.SortKey) bool {
+	for _, key := range keys {
+		if sortFields[key.Field] {
+			return true
+		}
+	}
+
+	return false
+}
+
+//row : a record along with the data computed from it
+type row struct {
+	record model.Record
+	data   model.Data
+}
+
+/*sortRows : sorts rows by the keys provided in place
+The sort is stable and rows which are equal on every key keep the order of their IDs.
+*/
+func sortRows(rows []row, keys []model.SortKey) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, key := range keys {
+			order := compareField(key.Field, rows[i], rows[j])
+
+			if key.Descending {
+				order = -order
+			}
+
+			if order != 0 {
+				return order < 0
+			}
+		}
+
+		return rows[i].record.ID < rows[j].record.ID
+	})
+}
+
+func compareField(field string, a, b row) int {
+	switch field {
+	case "id":
+		switch {
+		case a.record.ID < b.record.ID:
+			return -1
+		case a.record.ID > b.record.ID:
+			return 1
+		}
+
+		return 0
+	case "name":
+		// names are ordered like the repositories order them, whichever of the two sorted the page
+		return repository.CompareNoCase(a.record.Name, b.record.Name)
+	case "phone":
+		return strings.Compare(a.record.Phone, b.record.Phone)
+	case "country":
+		return strings.Compare(a.data.Country, b.data.Country)
+	case "state":
+		return strings.Compare(a.data.State, b.data.State)
+	}
+
+	return 0
+}
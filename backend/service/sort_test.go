@@ -0,0 +1,95 @@
+package service
+
+import (
+	"assessment/model"
+	repoMock "assessment/repository/mock"
+	"context"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParseSort(t *testing.T) {
+	require.Equal(t, []model.SortKey{
+		{Field: "country"},
+		{Field: "name", Descending: true},
+		{Field: "id", Descending: true},
+	}, parseSort([]string{"country", "-name", "country", "-id"}))
+}
+
+func TestNumberService_QuerySortedByComputedFields(t *testing.T) {
+	mockRepo := new(repoMock.PhoneNumberRepository)
+	mockRepo.On("LastModified", mock.Anything).Return(time.Time{}, nil)
+
+	mockRepo.On("ScanPhoneNumbers", mock.Anything, model.Filter{}, mock.Anything, mock.Anything).Run(scanTable(
+		"(256) 775069443",
+		"(237) 6A0311634",
+		"(212) 698054317",
+		"(237) 697151594",
+		"(256) 7503O6263",
+		"(237) 677046616",
+	)).Return(nil)
+
+	svc := NewNumberService(NewValidator(), mockRepo)
+
+	query := model.PhoneNumberQuery{
+		Sort: []model.SortKey{
+			{Field: "country"},
+			{Field: "state", Descending: true},
+		},
+		Pagination: model.Pagination{Page: 1, Limit: 4},
+	}
+
+	result, err := svc.Query(context.Background(), query)
+	require.NoError(t, err, "Expected: nil\nGot: %v\n", err)
+
+	var ids []int64
+
+	for _, d := range result.Data {
+		ids = append(ids, d.ID)
+	}
+
+	// Cameroon comes first with its OK numbers in order of ID ahead of the NOK one, followed by Morocco
+	require.Equal(t, []int64{4, 6, 2, 3}, ids)
+	require.True(t, result.Meta.Next)
+	require.False(t, result.Meta.Prev)
+
+	query.Pagination.Page = 2
+	query.Pagination.Offset = 4
+
+	result, err = svc.Query(context.Background(), query)
+	require.NoError(t, err, "Expected: nil\nGot: %v\n", err)
+
+	ids = nil
+
+	for _, d := range result.Data {
+		ids = append(ids, d.ID)
+	}
+
+	require.Equal(t, []int64{1, 5}, ids)
+	require.False(t, result.Meta.Next)
+	require.True(t, result.Meta.Prev)
+}
+
+//TestSortRows_Names : names are ordered like the repositories order them, only ASCII letters are folded to lower case
+func TestSortRows_Names(t *testing.T) {
+	rows := []row{
+		{record: model.Record{ID: 1, Name: "émile"}},
+		{record: model.Record{ID: 2, Name: "Émile"}},
+		{record: model.Record{ID: 3, Name: "Zed"}},
+		{record: model.Record{ID: 4, Name: "ava"}},
+		{record: model.Record{ID: 5, Name: "AVA"}},
+	}
+
+	sortRows(rows, []model.SortKey{{Field: "name"}})
+
+	var ids []int64
+
+	for _, r := range rows {
+		ids = append(ids, r.record.ID)
+	}
+
+	// É (C3 89) comes before é (C3 A9), and both after z
+	require.Equal(t, []int64{4, 5, 3, 2, 1}, ids)
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"assessment/model"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// validationBatchSize : the records validated by a worker at a time, large enough that handing them over costs little
+const validationBatchSize = 256
+
+/*validationPool : Converts records on a fixed number of goroutines, handing the results back in the order the records came in
+Records are read in batches, each batch is converted by whichever worker is free and the results are emitted batch by
+batch in the order they were read. At most two batches per worker are in flight, after which reading the repository
+waits for the slowest batch to be emitted, so a scan never holds more than that in memory however fast it's read.
+*/
+type validationPool struct {
+	workers int
+}
+
+//newValidationPool : A pool of the given number of workers, or of one per CPU when it's 0 or less
+func newValidationPool(workers int) *validationPool {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	return &validationPool{workers: workers}
+}
+
+//validationBatch : records read one after the other, along with where their results are handed over once converted
+type validationBatch struct {
+	records []model.Record
+	results chan []model.Data // holds a single value, so a worker never waits for the results to be emitted
+	err     error             // set before the results are handed over when converting the batch panicked
+}
+
+//panicError : a panic raised while scanning or converting, returned as an error so it fails the scan rather than the process
+type panicError struct {
+	value interface{}
+	stack []byte
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic while validating records: %v\n%s", e.value, e.stack)
+}
+
+//recovered : runs f, returning the panic it raised, if any, as a *panicError
+func recovered(f func()) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			err = &panicError{value: value, stack: debug.Stack()}
+		}
+	}()
+
+	f()
+
+	return nil
+}
+
+/*run : Converts every record the scan yields, calling emit with each record and its result in the order they were read
+Emitting stops once emit returns false, which stops the scan as well. The scan and every worker are done by the time
+run returns, so the scan can't go on using the repository behind the caller's back. A panic in the scan or in convert
+stops everything the same way and is returned as a *panicError, rather than taking the process down with it.
+*/
+func (p *validationPool) run(
+	scan func(yield func(model.Record) bool) error,
+	convert func(model.Record) model.Data,
+	emit func(model.Record, model.Data) bool,
+) error {
+	// a single worker is the serial loop, without any of the handing over
+	if p.workers == 1 {
+		var scanErr error
+
+		if err := recovered(func() {
+			scanErr = scan(func(record model.Record) bool { return emit(record, convert(record)) })
+		}); err != nil {
+			return err
+		}
+
+		return scanErr
+	}
+
+	var (
+		wg      sync.WaitGroup
+		scanErr error
+		failure error                                      // the first batch which failed to convert
+		stopped = make(chan struct{})                      // closed once emit returns false
+		batches = make(chan *validationBatch, 2*p.workers) // read but not yet picked up by a worker
+		order   = make(chan *validationBatch, 2*p.workers) // read but not yet emitted, in the order they were read
+	)
+
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for batch := range batches {
+				var data []model.Data
+
+				batch.err = recovered(func() { data = p.convert(batch.records, convert, stopped) })
+				batch.results <- data
+			}
+		}()
+	}
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		defer close(batches)
+		defer close(order)
+
+		var records []model.Record
+
+		// hands the records read so far over, waiting while too many batches are in flight
+		send := func() bool {
+			batch := &validationBatch{records: records, results: make(chan []model.Data, 1)}
+			records = make([]model.Record, 0, validationBatchSize)
+
+			select {
+			case order <- batch:
+			case <-stopped:
+				return false
+			}
+
+			select {
+			case batches <- batch:
+				return true
+			case <-stopped:
+				// the batch is already in order, it needs results for the emitter to get past it
+				batch.results <- nil
+				return false
+			}
+		}
+
+		if err := recovered(func() {
+			scanErr = scan(func(record model.Record) bool {
+				records = append(records, record)
+
+				return len(records) < validationBatchSize || send()
+			})
+		}); err != nil {
+			scanErr = err
+			return
+		}
+
+		if scanErr == nil && len(records) > 0 {
+			send()
+		}
+	}()
+
+	emitting := true
+
+	// the batches still in flight once emitting stops are drained, so nothing is left waiting on the emitter
+	for batch := range order {
+		data := <-batch.results
+
+		// the batches after one which failed are left unemitted, as if emit had returned false
+		if batch.err != nil && emitting {
+			failure, emitting = batch.err, false
+			close(stopped)
+		}
+
+		for index := 0; emitting && index < len(data); index++ {
+			if !emit(batch.records[index], data[index]) {
+				emitting = false
+				close(stopped)
+			}
+		}
+	}
+
+	wg.Wait()
+
+	if failure != nil {
+		return failure
+	}
+
+	// an error after emitting stopped comes from cutting the scan short, the caller already has what it asked for
+	if !emitting {
+		return nil
+	}
+
+	return scanErr
+}
+
+//convert : converts a batch of records, skipping it when emitting has already stopped
+func (p *validationPool) convert(records []model.Record, convert func(model.Record) model.Data, stopped <-chan struct{}) []model.Data {
+	select {
+	case <-stopped:
+		return nil
+	default:
+	}
+
+	data := make([]model.Data, len(records))
+
+	for index, record := range records {
+		data[index] = convert(record)
+	}
+
+	return data
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"assessment/config"
+	"assessment/model"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//numberedRecords : n records numbered from 1, whose phones are the IDs
+func numberedRecords(n int) []model.Record {
+	records := make([]model.Record, n)
+
+	for i := range records {
+		records[i] = model.Record{ID: int64(i + 1), Phone: strconv.Itoa(i + 1)}
+	}
+
+	return records
+}
+
+//scanOf : a scan over the records which stops as soon as yield returns false, like the repositories do
+func scanOf(records []model.Record, read *int64) func(yield func(model.Record) bool) error {
+	return func(yield func(model.Record) bool) error {
+		for _, record := range records {
+			atomic.AddInt64(read, 1)
+
+			if !yield(record) {
+				return nil
+			}
+		}
+
+		return nil
+	}
+}
+
+func convertID(record model.Record) model.Data {
+	return model.Data{ID: record.ID, PhoneNumber: record.Phone}
+}
+
+func TestValidationPool_Order(t *testing.T) {
+	records := numberedRecords(10*validationBatchSize + 7)
+
+	for _, workers := range []int{1, 2, 8} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			var read int64
+			var ids []int64
+
+			err := newValidationPool(workers).run(scanOf(records, &read), convertID, func(record model.Record, data model.Data) bool {
+				require.Equal(t, record.ID, data.ID)
+				ids = append(ids, data.ID)
+
+				return true
+			})
+
+			require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+			require.Len(t, ids, len(records))
+
+			// every record is emitted once, in the order it was read
+			for i, id := range ids {
+				require.Equal(t, int64(i+1), id)
+			}
+		})
+	}
+}
+
+func TestValidationPool_Stop(t *testing.T) {
+	records := numberedRecords(100 * validationBatchSize)
+	workers := 4
+
+	var read, converted int64
+	var ids []int64
+
+	convert := func(record model.Record) model.Data {
+		atomic.AddInt64(&converted, 1)
+		return convertID(record)
+	}
+
+	err := newValidationPool(workers).run(scanOf(records, &read), convert, func(record model.Record, data model.Data) bool {
+		ids = append(ids, data.ID)
+		return len(ids) < 10
+	})
+
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Equal(t, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, ids)
+
+	// reading stops once the batches in flight are full, rather than going through the whole table
+	inFlight := int64((4*workers + 2) * validationBatchSize)
+	require.LessOrEqual(t, atomic.LoadInt64(&read), inFlight)
+	require.LessOrEqual(t, atomic.LoadInt64(&converted), inFlight)
+}
+
+func TestValidationPool_ScanError(t *testing.T) {
+	failure := errors.New("connection lost")
+
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			var emitted int
+
+			scan := func(yield func(model.Record) bool) error {
+				for _, record := range numberedRecords(3 * validationBatchSize) {
+					if !yield(record) {
+						return nil
+					}
+				}
+
+				return failure
+			}
+
+			err := newValidationPool(workers).run(scan, convertID, func(model.Record, model.Data) bool {
+				emitted++
+				return true
+			})
+
+			require.ErrorIs(t, err, failure)
+
+			// the records read before the error are still emitted
+			require.Equal(t, 3*validationBatchSize, emitted)
+		})
+	}
+}
+
+func TestValidationPool_Panic(t *testing.T) {
+	records := numberedRecords(10 * validationBatchSize)
+
+	// a record in the middle of the third batch can't be converted
+	convert := func(record model.Record) model.Data {
+		if record.ID == 2*validationBatchSize+10 {
+			panic("broken record")
+		}
+
+		return convertID(record)
+	}
+
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			var read int64
+			var emitted int
+
+			err := newValidationPool(workers).run(scanOf(records, &read), convert, func(model.Record, model.Data) bool {
+				emitted++
+				return true
+			})
+
+			var panicked *panicError
+
+			require.ErrorAs(t, err, &panicked)
+			require.Equal(t, "broken record", panicked.value)
+
+			// nothing after the broken record is emitted
+			require.LessOrEqual(t, emitted, 2*validationBatchSize+9)
+		})
+
+		t.Run(fmt.Sprintf("scan with %d workers", workers), func(t *testing.T) {
+			scan := func(yield func(model.Record) bool) error {
+				yield(model.Record{ID: 1})
+				panic("connection broke")
+			}
+
+			err := newValidationPool(workers).run(scan, convertID, func(model.Record, model.Data) bool { return true })
+
+			var panicked *panicError
+
+			require.ErrorAs(t, err, &panicked)
+			require.Equal(t, "connection broke", panicked.value)
+		})
+	}
+}
+
+func TestNewValidationPool_Default(t *testing.T) {
+	require.Equal(t, runtime.GOMAXPROCS(0), newValidationPool(0).workers)
+	require.Equal(t, runtime.GOMAXPROCS(0), newValidationPool(-1).workers)
+	require.Equal(t, 3, newValidationPool(3).workers)
+}
+
+/*BenchmarkValidationPool : Validates the same numbers serially and on the pool, reporting how many numbers a second each gets through
+Every country is represented along with numbers which don't match any. The validator is cached with the default size
+like in production, so the workers contend for the cache's lock, and there are more numbers than it holds, so most of
+them still run the regular expressions the way a full scan of a large table does.
+*/
+func BenchmarkValidationPool(b *testing.B) {
+	codes := []string{"237", "251", "212", "258", "256", "999"}
+	records := make([]model.Record, 100000)
+
+	for i := range records {
+		records[i] = model.Record{ID: int64(i + 1), Phone: fmt.Sprintf("(%s) %09d", codes[i%len(codes)], 600000000+i*7919%100000000)}
+	}
+
+	// the pool gets a worker per CPU, so run with e.g. -cpu 1,4 to compare how it scales
+	for _, run := range []struct {
+		name    string
+		workers int
+	}{{"serial", 1}, {"pool", 0}} {
+		b.Run(run.name, func(b *testing.B) {
+			s := NewNumberService(NewCachedValidator(NewValidator(), config.DefaultValidationCacheSize), nil)
+			convert := func(record model.Record) model.Data { return s.toData(record, model.Search{}) }
+			pool := newValidationPool(run.workers)
+			start := time.Now()
+
+			for i := 0; i < b.N; i++ {
+				var read int64
+
+				err := pool.run(scanOf(records, &read), convert, func(model.Record, model.Data) bool { return true })
+
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportMetric(float64(b.N*len(records))/time.Since(start).Seconds(), "numbers/s")
+		})
+	}
+}
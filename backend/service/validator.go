@@ -5,44 +5,41 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
 )
 
-type country struct {
-	name string
-	code string
-}
+/*Validator : Validates phone numbers written as (code) national number against the rules of their country
+The country is the one with the longest dialling code the bracketed digits start with, so results never depend on the
+order rules were added in, and looking one up takes as long with 5 countries as with 200. When several countries share
+the code, the first one the number starts with the leading digits of, or else is valid in, is picked, and the main
+country of the code when there's none.
+*/
 type Validator struct {
-	CountryAndRegex map[country]*regexp.Regexp
+	mu      sync.RWMutex
+	codes   codeTrie
+	byName  map[string]*rule // keyed by the lower case name
+	version uint64           // bumped whenever a rule changes, so results cached elsewhere can be dropped
 }
 
-//NewValidator : Service To Be Used For Validation Of Country And Code.
+/*NewValidator : Service To Be Used For Validation Of Country And Code.
+Every country calling code is recognized from the embedded numbering plan, the countries the service started out with
+keep the regular expressions they've always been validated with.
+*/
 func NewValidator() *Validator {
+	v := &Validator{byName: make(map[string]*rule)}
 
-	// return a validator with preset information and regular expressions
-	return &Validator{
-		CountryAndRegex: map[country]*regexp.Regexp{
-			country{
-				name: "Cameroon",
-				code: "237",
-			}: regexp.MustCompile(`\((237)\) ?([2368]\d{7,8})$`),
-			country{
-				name: "Ethiopia",
-				code: "251",
-			}: regexp.MustCompile(`\((251)\) ?([1-59]\d{8})$`),
-			country{
-				name: "Morocco",
-				code: "212",
-			}: regexp.MustCompile(`\((212)\) ?([5-9]\d{8})$`),
-			country{
-				name: "Mozambique",
-				code: "258",
-			}: regexp.MustCompile(`\((258)\) ?([28]\d{7,8})$`),
-			country{
-				name: "Uganda",
-				code: "256",
-			}: regexp.MustCompile(`\((256)\) ?(\d{9})$`),
-		},
+	for _, t := range numberingPlan() {
+		v.add(&rule{name: t.name, code: t.code, plusCode: "+" + t.code, plan: t}, false)
 	}
+
+	// preset information and regular expressions
+	v.preset("Cameroon", "237", regexp.MustCompile(`\((237)\) ?([2368]\d{7,8})$`))
+	v.preset("Ethiopia", "251", regexp.MustCompile(`\((251)\) ?([1-59]\d{8})$`))
+	v.preset("Morocco", "212", regexp.MustCompile(`\((212)\) ?([5-9]\d{8})$`))
+	v.preset("Mozambique", "258", regexp.MustCompile(`\((258)\) ?([28]\d{7,8})$`))
+	v.preset("Uganda", "256", regexp.MustCompile(`\((256)\) ?(\d{9})$`))
+
+	return v
 }
 
 /*Validate : checks whether the input phone number is valid
@@ -53,58 +50,136 @@ func NewValidator() *Validator {
         - valid   <bool>
 */
 func (v *Validator) Validate(phone string) (string, string, string, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
 	// trim leading and trailing spaces from the input to avoid true negatives
 	phone = strings.TrimSpace(phone)
 
-	// loop through all countries and their regular expressions
-	for country, regex := range v.CountryAndRegex {
-		// check if the phone number starts with the country code of the current country
-		if strings.HasPrefix(phone, fmt.Sprintf("(%s)", country.code)) {
-			// check whether the phone number conforms to the registered validation regular expression
-			if regex.MatchString(phone) {
+	end := strings.IndexByte(phone, ')')
+
+	// only numbers starting with a bracketed dialling code belong to a country
+	if !strings.HasPrefix(phone, "(") || end < 0 || !isCode(phone[1:end]) {
+		return "", "", "", false
+	}
+
+	digits := phone[1:end]
+
+	rules, length := v.codes.longest(digits)
+
+	// there's no match at all, return zero values.
+	if len(rules) == 0 {
+		return "", "", "", false
+	}
 
-				// get all the submatches of the regular expression, which are the country code and phone number (without country code)
-				subMatches := regex.FindAllStringSubmatch(phone, -1)
+	number := strings.TrimSpace(phone[end+1:])
 
-				// it always will be on the first row
-				// submatch returns only the number if it doesn't have a submatch.
-				subMatch := subMatches[0]
+	// the bracketed digits go on past the country's code, e.g. (1242) for the Bahamas whose code is 1, so they start
+	// the national number and the number is validated as if it had been written with the code alone
+	if length < len(digits) {
+		number = digits[length:] + number
+		phone = "(" + rules[0].code + ") " + number
+	}
 
-				// return the country, extracted submatches and a value of true for validation.
-				return country.name, fmt.Sprintf("+%s", subMatch[1]), subMatch[2], true
+	for _, r := range rules {
+		// a country told apart by its leading digits owns every number starting with them, valid or not
+		if r.hasLeadingDigits() {
+			if !r.leads(number) {
+				continue
 			}
 
-			// if the number doesn't match the regex then it is not valid.
-			// the country code and ordinary number need to be extracted
-			sanitized := strings.TrimSpace(phone[strings.Index(phone, ")")+1:])
+			national, valid := r.match(phone, number)
 
-			return country.name, fmt.Sprintf("+%s", country.code), sanitized, false
+			return r.name, r.plusCode, national, valid
+		}
+
+		if national, valid := r.match(phone, number); valid {
+			return r.name, r.plusCode, national, true
 		}
 	}
 
-	// there's no match at all, return zero values.
-	return "", "", "", false
+	// if the number isn't valid in any of them then it is not valid, and belongs to the main country of the code.
+	return rules[0].name, rules[0].plusCode, number, false
 }
 
 // GetCodeFromCountry : Get's the country code from the input country.
 func (v *Validator) GetCodeFromCountry(name string) (string, error) {
-	// loop through the default registered countries
-	for country := range v.CountryAndRegex {
-		// if the input country matches  the requested  country, return it's country code.
-		if strings.ToLower(country.name) == strings.ToLower(name) {
-			return country.code, nil
-		}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	// if the input country matches a registered country, return it's country code.
+	if r, ok := v.byName[strings.ToLower(name)]; ok {
+		return r.code, nil
 	}
 
 	// return a not found error
 	return "", apperror.NotFound
 }
 
+/*SetRule : Adds a country or replaces the regular expression its numbers are validated with
+The regular expression is matched against the whole number written as (code) national number and has to capture the code
+and the national number, in that order. The code has to be made of digits only. The country takes the code over, any
+other country registered under it is dropped.
+*/
+func (v *Validator) SetRule(name, code string, regex *regexp.Regexp) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	r := newRule(name, code, regex)
+
+	v.remove(v.byName[strings.ToLower(name)])
+
+	for _, old := range v.codes.rules(code) {
+		v.remove(old)
+	}
+
+	v.add(r, true)
+	v.version++
+}
+
+//preset : replaces the numbering plan of a country with a regular expression, leaving the countries sharing its code be
+func (v *Validator) preset(name, code string, regex *regexp.Regexp) {
+	r := newRule(name, code, regex)
+
+	v.remove(v.byName[strings.ToLower(name)])
+	v.add(r, true)
+}
+
+func newRule(name, code string, regex *regexp.Regexp) *rule {
+	if !isCode(code) {
+		panic(fmt.Sprintf("the dialling code of %s should be made of digits, got %q", name, code))
+	}
+
+	return &rule{name: name, code: code, plusCode: "+" + code, regex: regex}
+}
+
+//add : registers the rule under its code and the country's name
+func (v *Validator) add(r *rule, first bool) {
+	v.byName[strings.ToLower(r.name)] = r
+	v.codes.add(r, first)
+}
+
+//remove : forgets the rule, if there's one
+func (v *Validator) remove(r *rule) {
+	if r == nil {
+		return
+	}
+
+	delete(v.byName, strings.ToLower(r.name))
+	v.codes.remove(r)
+}
+
+//RulesVersion : A number which changes whenever the rules change
+func (v *Validator) RulesVersion() uint64 {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.version
+}
+
 /*func (v *Validator) GetCountryFromCode(code string) (string, error) {
-	for country := range v.CountryAndRegex {
-		if country.code == code {
-			return country.name, nil
-		}
+	if rules := v.codes.rules(code); len(rules) > 0 {
+		return rules[0].name, nil
 	}
 
 	return "", apperror.NotFound
@@ -3,6 +3,7 @@ package service
 import (
 	"assessment/model"
 	"github.com/stretchr/testify/require"
+	"regexp"
 	"testing"
 )
 
@@ -31,7 +32,21 @@ func TestValidator_GetCodeFromCountry(t *testing.T) {
 		require.Equal(t, expected[index], code)
 	}
 
-	_, err := v.GetCodeFromCountry("Nigeria")
+	// every other country comes from the numbering plan
+	code, err := v.GetCodeFromCountry("Nigeria")
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Equal(t, "234", code)
+
+	// countries sharing a code are all looked up under it, the Bahamas' numbers written (1242) are resolved to code 1
+	code, err = v.GetCodeFromCountry("Bahamas")
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Equal(t, "1", code)
+
+	country, plusCode, _, _ := v.Validate("(1242) 3591234")
+	require.Equal(t, "Bahamas", country)
+	require.Equal(t, "+"+code, plusCode)
+
+	_, err = v.GetCodeFromCountry("Atlantis")
 	require.Error(t, err, "Expected An Error\nGet: %v\n", err)
 }
 
@@ -132,3 +147,62 @@ func TestValidator_Validate(t *testing.T) {
 	require.Empty(t, number)
 	require.False(t, valid)
 }
+
+func TestValidator_OverlappingCodes(t *testing.T) {
+	v := NewValidator()
+
+	v.SetRule("United States", "1", regexp.MustCompile(`\((1)\) ?([2-9]\d{9})$`))
+	v.SetRule("Bahamas", "1242", regexp.MustCompile(`\((1242)\) ?([2-9]\d{6})$`))
+
+	var testCases = []struct {
+		phone string
+		data  model.Data
+	}{
+		{"(1242) 3591234", model.Data{Country: "Bahamas", CountryCode: "+1242", PhoneNumber: "3591234", State: "OK"}},
+		{"(1) 2125550100", model.Data{Country: "United States", CountryCode: "+1", PhoneNumber: "2125550100", State: "OK"}},
+		{"(1242) 12", model.Data{Country: "Bahamas", CountryCode: "+1242", PhoneNumber: "12", State: "NOK"}},
+		// the digits after the longest known code start the national number
+		{"(1809) 5550100", model.Data{Country: "United States", CountryCode: "+1", PhoneNumber: "8095550100", State: "OK"}},
+		{"(12)", model.Data{Country: "United States", CountryCode: "+1", PhoneNumber: "2", State: "NOK"}},
+		{"(1 242) 3591234", model.Data{}},
+		{"1242 3591234", model.Data{}},
+		{"()", model.Data{}},
+	}
+
+	// the same number always gets the same answer, whatever order the rules were added in
+	for i := 0; i < 10; i++ {
+		for _, tCase := range testCases {
+			country, code, number, valid := v.Validate(tCase.phone)
+			require.Equal(t, tCase.data.Country, country, tCase.phone)
+			require.Equal(t, tCase.data.CountryCode, code, tCase.phone)
+			require.Equal(t, tCase.data.PhoneNumber, number, tCase.phone)
+			require.Equal(t, tCase.data.State == "OK", valid, tCase.phone)
+		}
+	}
+}
+
+func TestValidator_SetRule(t *testing.T) {
+	v := NewValidator()
+
+	// renaming a country drops the rule it had under its old name
+	v.SetRule("Republic of Cameroon", "237", regexp.MustCompile(`\((237)\) ?(\d{9})$`))
+
+	_, err := v.GetCodeFromCountry("Cameroon")
+	require.Error(t, err, "Expected An Error\nGet: %v\n", err)
+
+	country, _, _, valid := v.Validate("(237) 123456789")
+	require.Equal(t, "Republic of Cameroon", country)
+	require.True(t, valid)
+
+	// moving a country to another code frees the old one
+	v.SetRule("Uganda", "2560", regexp.MustCompile(`\((2560)\) ?(\d{9})$`))
+
+	country, _, _, _ = v.Validate("(256) 704244430")
+	require.Empty(t, country)
+
+	code, err := v.GetCodeFromCountry("uganda")
+	require.NoError(t, err, "Expected No Error\nGot: %v\n", err)
+	require.Equal(t, "2560", code)
+
+	require.Panics(t, func() { v.SetRule("Nowhere", "+99", regexp.MustCompile(`.*`)) })
+}
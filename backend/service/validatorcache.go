@@ -0,0 +1,168 @@
+package service
+
+import (
+	"container/list"
+	"sync"
+)
+
+//rulesVersioner : a validator which can tell when its rules change
+type rulesVersioner interface {
+	RulesVersion() uint64
+}
+
+//validation : the result of validating a phone number
+type validation struct {
+	phone   string
+	country string
+	code    string
+	number  string
+	valid   bool
+}
+
+//ValidationCacheStats : How well the validation cache is doing
+type ValidationCacheStats struct {
+	Hits      uint64  `json:"hits"`
+	Misses    uint64  `json:"misses"`
+	Evictions uint64  `json:"evictions"`
+	HitRate   float64 `json:"hitRate"` // the share of lookups answered from the cache, 0 before the first lookup
+	Size      int     `json:"size"`
+	Capacity  int     `json:"capacity"`
+}
+
+/*CachedValidator : Wraps a validator with a bounded cache of its results, keyed by the raw phone number
+The least recently used result is dropped once the cache is full. When the wrapped validator tells when its rules change
+(through RulesVersion), every cached result is dropped as soon as they do.
+*/
+type CachedValidator struct {
+	validator NumberValidator
+	capacity  int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently used first
+	version uint64
+	stats   ValidationCacheStats
+}
+
+//NewCachedValidator : Creates a cache holding up to capacity results of the validator, a capacity below 1 caches nothing
+func NewCachedValidator(validator NumberValidator, capacity int) *CachedValidator {
+	cache := &CachedValidator{
+		validator: validator,
+		capacity:  capacity,
+		entries:   make(map[string]*list.Element),
+		order:     list.New(),
+	}
+
+	if versioned, ok := validator.(rulesVersioner); ok {
+		cache.version = versioned.RulesVersion()
+	}
+
+	return cache
+}
+
+//Validate : Validates the phone number, reusing the last result for the same number while the rules haven't changed
+func (c *CachedValidator) Validate(phone string) (string, string, string, bool) {
+	c.mu.Lock()
+
+	c.checkRules()
+
+	if element, ok := c.entries[phone]; ok {
+		c.order.MoveToFront(element)
+		c.stats.Hits++
+
+		result := element.Value.(*validation)
+		c.mu.Unlock()
+
+		return result.country, result.code, result.number, result.valid
+	}
+
+	c.stats.Misses++
+	version := c.version
+	c.mu.Unlock()
+
+	// validated without holding the lock so lookups from other requests aren't held up by the regular expressions
+	country, code, number, valid := c.validator.Validate(phone)
+
+	c.store(&validation{phone: phone, country: country, code: code, number: number, valid: valid}, version)
+
+	return country, code, number, valid
+}
+
+//GetCodeFromCountry : Gets the country code of the country, these lookups are cheap enough not to be cached
+func (c *CachedValidator) GetCodeFromCountry(name string) (string, error) {
+	return c.validator.GetCodeFromCountry(name)
+}
+
+//Invalidate : Drops every cached result, for when the rules change in a way the validator can't tell
+func (c *CachedValidator) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.clear()
+}
+
+//Stats : The hits, misses and evictions of the cache so far, and how full it is
+func (c *CachedValidator) Stats() ValidationCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Size = c.order.Len()
+	stats.Capacity = c.capacity
+
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(lookups)
+	}
+
+	return stats
+}
+
+//store : caches a result worked out under the given rules version, dropping the least recently used one when full
+func (c *CachedValidator) store(result *validation, version uint64) {
+	if c.capacity < 1 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// results worked out with rules which have since changed are stale already
+	if c.checkRules(); c.version != version {
+		return
+	}
+
+	// another request may have validated the same number in the meantime
+	if element, ok := c.entries[result.phone]; ok {
+		element.Value = result
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[result.phone] = c.order.PushFront(result)
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*validation).phone)
+		c.stats.Evictions++
+	}
+}
+
+//checkRules : drops every cached result when the rules of the validator have changed, the lock has to be held
+func (c *CachedValidator) checkRules() {
+	versioned, ok := c.validator.(rulesVersioner)
+
+	if !ok {
+		return
+	}
+
+	if version := versioned.RulesVersion(); version != c.version {
+		c.version = version
+		c.clear()
+	}
+}
+
+func (c *CachedValidator) clear() {
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	serviceMock "assessment/service/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestCachedValidator_Validate(t *testing.T) {
+	mockValidator := new(serviceMock.NumberValidator)
+
+	mockValidator.On("Validate", "(237) 697151594").Return("Cameroon", "+237", "697151594", true)
+	mockValidator.On("Validate", "(212) 6546545369").Return("Morocco", "+212", "6546545369", false)
+	mockValidator.On("Validate", "(256) 775069443").Return("Uganda", "+256", "775069443", true)
+
+	cache := NewCachedValidator(mockValidator, 2)
+
+	country, code, number, valid := cache.Validate("(237) 697151594")
+	require.Equal(t, []interface{}{"Cameroon", "+237", "697151594", true}, []interface{}{country, code, number, valid})
+
+	country, code, number, valid = cache.Validate("(237) 697151594")
+	require.Equal(t, []interface{}{"Cameroon", "+237", "697151594", true}, []interface{}{country, code, number, valid})
+
+	// the second lookup was answered from the cache
+	mockValidator.AssertNumberOfCalls(t, "Validate", 1)
+
+	cache.Validate("(212) 6546545369")
+	cache.Validate("(237) 697151594")
+
+	// the cache is full, so the least recently used number (Morocco) makes way for Uganda
+	cache.Validate("(256) 775069443")
+	cache.Validate("(237) 697151594")
+	cache.Validate("(212) 6546545369")
+
+	mockValidator.AssertNumberOfCalls(t, "Validate", 4)
+
+	require.Equal(t, ValidationCacheStats{
+		Hits:      3,
+		Misses:    4,
+		Evictions: 2,
+		HitRate:   3.0 / 7.0,
+		Size:      2,
+		Capacity:  2,
+	}, cache.Stats())
+
+	cache.Invalidate()
+	cache.Validate("(237) 697151594")
+
+	mockValidator.AssertNumberOfCalls(t, "Validate", 5)
+	require.Equal(t, 1, cache.Stats().Size)
+}
+
+func TestCachedValidator_Disabled(t *testing.T) {
+	mockValidator := new(serviceMock.NumberValidator)
+
+	mockValidator.On("Validate", mock.Anything).Return("Cameroon", "+237", "697151594", true)
+
+	cache := NewCachedValidator(mockValidator, 0)
+
+	cache.Validate("(237) 697151594")
+	cache.Validate("(237) 697151594")
+
+	mockValidator.AssertNumberOfCalls(t, "Validate", 2)
+	require.Equal(t, 0, cache.Stats().Size)
+}
+
+func TestCachedValidator_RulesChange(t *testing.T) {
+	validator := NewValidator()
+	cache := NewCachedValidator(validator, 100)
+
+	_, _, _, valid := cache.Validate("(237) 12345")
+	require.False(t, valid)
+
+	// a rule which accepts the number drops the result cached under the old rule
+	validator.SetRule("Cameroon", "237", regexp.MustCompile(`\((237)\) ?(\d{5})$`))
+
+	country, code, number, valid := cache.Validate("(237) 12345")
+	require.Equal(t, []interface{}{"Cameroon", "+237", "12345", true}, []interface{}{country, code, number, valid})
+	require.Equal(t, uint64(0), cache.Stats().Hits)
+}
+
+func TestCachedValidator_Concurrent(t *testing.T) {
+	validator := NewValidator()
+	cache := NewCachedValidator(validator, 50)
+
+	var wg sync.WaitGroup
+
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+
+		go func(worker int) {
+			defer wg.Done()
+
+			for i := 0; i < 200; i++ {
+				phone := "(237) 6971515" + strconv.Itoa(10+i%80)
+
+				// a cached result has to be the one the validator gives
+				country, code, number, valid := cache.Validate(phone)
+				expCountry, expCode, expNumber, expValid := validator.Validate(phone)
+
+				assert.Equal(t, []interface{}{expCountry, expCode, expNumber, expValid}, []interface{}{country, code, number, valid})
+			}
+		}(worker)
+	}
+
+	wg.Wait()
+
+	stats := cache.Stats()
+	require.Equal(t, uint64(1600), stats.Hits+stats.Misses)
+	require.LessOrEqual(t, stats.Size, 50)
+}
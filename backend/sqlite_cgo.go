@@ -0,0 +1,18 @@
+//go:build cgo
+
+package main
+
+import (
+	"assessment/infra/db/sqlite"
+	"assessment/repository"
+)
+
+//newSqliteRepository : Opens the SQLite database at DB_FILE_NAME
+func newSqliteRepository() (repository.PhoneNumberRepository, error) {
+	return sqlite.NewSqliteClient()
+}
+
+//createSearchIndex : Creates the full text index of the SQLite database at DB_FILE_NAME
+func createSearchIndex() error {
+	return sqlite.CreateSearchIndex()
+}
@@ -0,0 +1,18 @@
+//go:build !cgo
+
+package main
+
+import (
+	"assessment/repository"
+	"errors"
+)
+
+//newSqliteRepository : The SQLite driver is written in C, so builds without cgo can only use the other databases
+func newSqliteRepository() (repository.PhoneNumberRepository, error) {
+	return nil, errors.New("SQLite isn't available in builds without cgo, set DB_DRIVER to memory or postgres instead")
+}
+
+//createSearchIndex : There's no SQLite database to index in builds without cgo
+func createSearchIndex() error {
+	return errors.New("SQLite isn't available in builds without cgo")
+}
@@ -0,0 +1,10 @@
+module numberingplan
+
+go 1.23.0
+
+require (
+	github.com/nyaruka/phonenumbers v1.8.1
+	golang.org/x/text v0.23.0
+)
+
+require google.golang.org/protobuf v1.36.11 // indirect
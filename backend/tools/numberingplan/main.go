@@ -0,0 +1,199 @@
+/*numberingplan : Writes the numbering plan the validator embeds, exported from libphonenumber's metadata
+Territories are listed by calling code, the main country of a code first and the others in the order libphonenumber
+checks them. It's a module of its own so that libphonenumber isn't a dependency of the service, update the plan with
+	go generate ./service
+*/
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/nyaruka/phonenumbers"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+	"log"
+	"os"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the region libphonenumber files calling codes which don't belong to a country under
+const nonGeographic = "001"
+
+// nonGeographicNames : the names of the calling codes which don't belong to a country, as the ITU lists them
+var nonGeographicNames = map[int32]string{
+	800: "International Freephone Service",
+	808: "International Shared Cost Service",
+	870: "Inmarsat",
+	878: "Universal Personal Telecommunications",
+	881: "Global Mobile Satellite System",
+	882: "International Networks (882)",
+	883: "International Networks (883)",
+	888: "United Nations Office for the Coordination of Humanitarian Affairs",
+	979: "International Premium Rate Service",
+}
+
+//territory : the numbering plan of a country, or of a calling code which doesn't belong to one
+type territory struct {
+	ID            string                `json:"id"`
+	Name          string                `json:"name"`
+	Code          string                `json:"code"`
+	LeadingDigits string                `json:"leadingDigits,omitempty"`
+	Lengths       []int32               `json:"lengths"`
+	Types         map[string]numberType `json:"types"`
+}
+
+//numberType : the national numbers of one type, e.g. mobile, the lengths are left out when they're the territory's
+type numberType struct {
+	Pattern string  `json:"pattern"`
+	Lengths []int32 `json:"lengths,omitempty"`
+}
+
+func main() {
+	collection, err := phonenumbers.MetadataCollection()
+
+	if err != nil {
+		log.Fatalf("reading the metadata: %v", err)
+	}
+
+	byRegion := make(map[string]*phonenumbers.PhoneMetadata)
+	byCode := make(map[int32]*phonenumbers.PhoneMetadata)
+
+	for _, metadata := range collection.GetMetadata() {
+		if metadata.GetId() == nonGeographic {
+			byCode[metadata.GetCountryCode()] = metadata
+		} else {
+			byRegion[metadata.GetId()] = metadata
+		}
+	}
+
+	var codes []int
+
+	for code := range phonenumbers.GetSupportedCallingCodes() {
+		codes = append(codes, code)
+	}
+
+	sort.Ints(codes)
+
+	var territories []territory
+
+	names := make(map[string]string)
+
+	for _, code := range codes {
+		// the main country of a code comes first, the others in the order libphonenumber checks them
+		for _, region := range phonenumbers.GetRegionCodesForCountryCode(code) {
+			metadata := byRegion[region]
+
+			if region == nonGeographic {
+				metadata = byCode[int32(code)]
+			}
+
+			if metadata == nil {
+				log.Fatalf("there's no metadata for %s (+%d)", region, code)
+			}
+
+			t := newTerritory(metadata)
+
+			if other, ok := names[strings.ToLower(t.Name)]; ok {
+				log.Fatalf("%s and %s are both named %q", other, t.ID, t.Name)
+			}
+
+			names[strings.ToLower(t.Name)] = t.ID
+			territories = append(territories, t)
+		}
+	}
+
+	if err := write(territories); err != nil {
+		log.Fatalf("writing the numbering plan: %v", err)
+	}
+}
+
+func newTerritory(metadata *phonenumbers.PhoneMetadata) territory {
+	t := territory{
+		ID:            metadata.GetId(),
+		Code:          strconv.Itoa(int(metadata.GetCountryCode())),
+		LeadingDigits: metadata.GetLeadingDigits(),
+		Lengths:       metadata.GetGeneralDesc().GetPossibleLength(),
+		Types:         make(map[string]numberType),
+	}
+
+	if t.ID == nonGeographic {
+		t.Name = nonGeographicNames[metadata.GetCountryCode()]
+	} else {
+		t.Name = display.English.Regions().Name(language.MustParseRegion(t.ID))
+	}
+
+	if t.Name == "" {
+		log.Fatalf("%s (+%s) doesn't have a name", t.ID, t.Code)
+	}
+
+	for name, desc := range map[string]*phonenumbers.PhoneNumberDesc{
+		"fixedLine":      metadata.GetFixedLine(),
+		"mobile":         metadata.GetMobile(),
+		"tollFree":       metadata.GetTollFree(),
+		"premiumRate":    metadata.GetPremiumRate(),
+		"sharedCost":     metadata.GetSharedCost(),
+		"personalNumber": metadata.GetPersonalNumber(),
+		"voip":           metadata.GetVoip(),
+		"pager":          metadata.GetPager(),
+		"uan":            metadata.GetUan(),
+		"voicemail":      metadata.GetVoicemail(),
+	} {
+		lengths := desc.GetPossibleLength()
+
+		// types a territory doesn't have come without a pattern or with NA for one, or with a length of -1
+		if pattern := desc.GetNationalNumberPattern(); pattern == "" || pattern == "NA" || (len(lengths) == 1 && lengths[0] == -1) {
+			continue
+		}
+
+		t.Types[name] = numberType{Pattern: desc.GetNationalNumberPattern(), Lengths: lengths}
+	}
+
+	return t
+}
+
+//write : writes the numbering plan to stdout, a territory per line so updates are easy to review
+func write(territories []territory) error {
+	out := bufio.NewWriter(os.Stdout)
+
+	source := "libphonenumber metadata"
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == "github.com/nyaruka/phonenumbers" {
+				source = fmt.Sprintf("libphonenumber metadata from %s %s", dep.Path, dep.Version)
+			}
+		}
+	}
+
+	header, err := json.Marshal(source)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "{\"source\": %s,\n\"territories\": [\n", header)
+
+	for index, t := range territories {
+		line, err := json.Marshal(t)
+
+		if err != nil {
+			return err
+		}
+
+		separator := ","
+
+		if index == len(territories)-1 {
+			separator = ""
+		}
+
+		fmt.Fprintf(out, "%s%s\n", line, separator)
+	}
+
+	fmt.Fprint(out, "]}\n")
+
+	return out.Flush()
+}